@@ -8,7 +8,6 @@ import (
 	"log"
 	"math"
 	"os"
-	"sort"
 	"time"
 )
 
@@ -124,34 +123,16 @@ func evalPlurality(forest rbf.RandomBinaryForest, test [][]byte, trainLabels, te
 	return matchCount
 }
 
-func l2Dist(v1, v2 []byte) int {
-	distSquared := 0
-	for i := range v1 {
-		diff := v1[i] - v2[i]
-		distSquared += int(diff * diff)
-	}
-	return distSquared
-}
-
 func evalOneImageL2(forest rbf.RandomBinaryForest, train [][]byte, testImage []byte, trainLabels []byte, testLabel byte, numNeighbors int32) bool {
-	// query forest
-	resultsCount, allTreeResults := forest.FindPointAllResults(testImage)
-	// combine all returned results into single slice
-	allResults := make([]int32, resultsCount)
-	for _, treeResults := range allTreeResults {
-		for _, result := range treeResults {
-			allResults = append(allResults, result)
-		}
+	// query forest and get the k nearest by L2 distance to test point
+	neighbors, err := forest.WithTrainingData(train).WithDistance(rbf.L2).FindNearest(testImage, int(numNeighbors))
+	if err != nil {
+		panic(err)
 	}
-
-	// order them by L2 distance to test point
-	sort.Slice(allResults, func(i, j int) bool {
-		return l2Dist(testImage, train[allResults[i]]) < l2Dist(testImage, train[allResults[j]])
-	})
-	// pick out the k nearest ones
+	// plurality-vote among them
 	labelCounts := make([]int, 10)
-	for _, index := range allResults[:numNeighbors] {
-		labelCounts[trainLabels[index]] += 1
+	for _, neighbor := range neighbors {
+		labelCounts[trainLabels[neighbor.Index]] += 1
 	}
 	argmaxLabel := argmax(labelCounts)
 	return byte(argmaxLabel) == testLabel
@@ -159,10 +140,10 @@ func evalOneImageL2(forest rbf.RandomBinaryForest, train [][]byte, testImage []b
 
 //func evalOneImageL2(forest rbf.RandomBinaryForest, train [][]byte, testImage []byte, trainLabels []byte, testLabel byte) bool {
 //    allResults := forest.FindPointDedupResults(testImage)
-//    minDist := math.MaxInt64
+//    minDist := math.MaxFloat64
 //    minDistIndex := int32(-1)
 //    for index := range allResults {
-//        if dist := l2Dist(testImage, train[index]); dist < minDist {
+//        if dist := rbf.L2.Distance(testImage, train[index]); dist < minDist {
 //            minDist = dist
 //            minDistIndex = index
 //        }
@@ -25,6 +25,10 @@ func (b bigrams) FromStringInPlace(input string, featureArray []byte) {
 	}
 }
 
+func (b bigrams) Names() []string {
+	return charPairNames("bigrams")
+}
+
 func deserializeBigramsMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	if allowRepeats, ok := confMap["allow_repeats"]; ok {
 		maxBigramCount := 1
@@ -24,6 +24,7 @@ package features
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -74,6 +75,17 @@ func CreateFeatureCalcFuncs(confStr string) (func(string) []byte, func([]string)
 	return fromString, fromStringArray
 }
 
+// Given a feature-set config string, get human-readable names for the features, in the same order
+// as the feature-arrays calculated by the functions from `CreateFeatureCalcFuncs`. Names look like
+// "<feature_type>:<detail>", e.g. "bigrams:ab" or "first_number:0".
+func CreateFeatureNames(confStr string) []string {
+	var names []string
+	for _, config := range getConfigsFromYaml(confStr) {
+		names = append(names, config.Names()...)
+	}
+	return names
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 // All code below is private.
 
@@ -85,6 +97,10 @@ type featureSetConfig interface {
 	// Given the input string s, put features for s into the given byte-slice.
 	// Note: we do no position or size checking on the slice.
 	FromStringInPlace(s string, features []byte)
+
+	// Human-readable names for each of the features in this feature-set (so Size() of them),
+	// in the same order as FromStringInPlace puts them in the byte-slice.
+	Names() []string
 }
 
 const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789 "
@@ -122,6 +138,38 @@ func init() {
 	}
 }
 
+// Names for character-pair features (bigrams, followgrams), indexed the same way as the features.
+func charPairNames(featureType string) []string {
+	names := make([]string, alphabet_size*alphabet_size)
+	for i := 0; i < alphabet_size; i++ {
+		for j := 0; j < alphabet_size; j++ {
+			names[(i*alphabet_size)+j] = featureType + ":" + alphabet[i:i+1] + alphabet[j:j+1]
+		}
+	}
+	return names
+}
+
+// Names for features that are repeated `count` times (poor man's weighting).
+func repeatedNames(featureType string, count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = featureType + ":" + strconv.Itoa(i)
+	}
+	return names
+}
+
+// Names for per-character features that are repeated (or have one copy per occurrence)
+// `count` times, indexed the same way as the features.
+func repeatedCharNames(featureType string, count int) []string {
+	names := make([]string, count*alphabet_size)
+	for i := 0; i < count; i++ {
+		for j := 0; j < alphabet_size; j++ {
+			names[(i*alphabet_size)+j] = featureType + ":" + strconv.Itoa(i) + ":" + alphabet[j:j+1]
+		}
+	}
+	return names
+}
+
 func normalizeString(s string) string {
 	return non_alnum_pattern.ReplaceAllLiteralString(strings.ToLower(s), " ")
 }
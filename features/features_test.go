@@ -133,3 +133,38 @@ func TestReadSerializedConfigFromString(t *testing.T) {
 		t.Errorf("expected configs[5] (%v) to be followgrams{%d}", configs[5], followgram_default_window_size)
 	}
 }
+
+func TestCreateFeatureNames(t *testing.T) {
+	// given:
+	featureConfig := `
+- feature_type: first_number
+  count: 2
+- feature_type: bigrams
+  allow_repeats: true
+- feature_type: occurrence_positions
+  direction_is_head: false
+  num_occurrences: 2
+`
+	// when:
+	names := CreateFeatureNames(featureConfig)
+	// then there's one name per feature, in feature-array order:
+	calculateFeatures, _ := CreateFeatureCalcFuncs(featureConfig)
+	if len(names) != len(calculateFeatures("")) {
+		t.Errorf("got %d names for %d features", len(names), len(calculateFeatures("")))
+	}
+	bigramsStart := 2
+	positionsStart := bigramsStart + (alphabet_size * alphabet_size)
+	expected := map[int]string{
+		0:                                  "first_number:0",
+		1:                                  "first_number:1",
+		bigramsStart + 1:                   "bigrams:ab",
+		bigramsStart + 39:                  "bigrams:bc",
+		positionsStart:                     "occurrence_positions:tail:0:a",
+		positionsStart + alphabet_size + 2: "occurrence_positions:tail:1:c",
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("names[%d] == %q; expected %q", i, names[i], name)
+		}
+	}
+}
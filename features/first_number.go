@@ -28,6 +28,10 @@ func (fn firstNumber) FromStringInPlace(input string, featureArray []byte) {
 	}
 }
 
+func (fn firstNumber) Names() []string {
+	return repeatedNames("first_number", int(fn.Count))
+}
+
 func deserializeFirstNumberMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	if countStr, ok := confMap["count"]; ok {
 		if count, err := strconv.Atoi(countStr); err == nil {
@@ -65,6 +65,10 @@ func (f followgrams) FromStringInPlace(input string, featureArray []byte) {
 	}
 }
 
+func (f followgrams) Names() []string {
+	return charPairNames("followgrams")
+}
+
 func deserializeFollowgramsMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	if windowSizeStr, ok := confMap["window_size"]; ok {
 		if windowSize, err := strconv.Atoi(windowSizeStr); err == nil {
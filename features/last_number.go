@@ -28,6 +28,10 @@ func (fn lastNumber) FromStringInPlace(input string, featureArray []byte) {
 	}
 }
 
+func (fn lastNumber) Names() []string {
+	return repeatedNames("last_number", int(fn.Count))
+}
+
 func deserializeLastNumberMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	if countStr, ok := confMap["count"]; ok {
 		if count, err := strconv.Atoi(countStr); err == nil {
@@ -31,6 +31,10 @@ func (o occurrenceCounts) FromStringInPlace(input string, featureArray []byte) {
 	}
 }
 
+func (o occurrenceCounts) Names() []string {
+	return repeatedCharNames("occurrence_counts", int(o.Count))
+}
+
 func deserializeOccurrenceCountsMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	if countStr, ok := confMap["count"]; ok {
 		if count, err := strconv.Atoi(countStr); err == nil {
@@ -64,6 +64,14 @@ func (o occurrencePositions) FromStringInPlace(input string, featureArray []byte
 	}
 }
 
+// e.g. "occurrence_positions:head:0:a" is the position of the first 'a' from the head
+func (o occurrencePositions) Names() []string {
+	if o.DirectionIsHead {
+		return repeatedCharNames("occurrence_positions:head", int(o.NumberOfOccurrences))
+	}
+	return repeatedCharNames("occurrence_positions:tail", int(o.NumberOfOccurrences))
+}
+
 func deserializeOccurrencePositionsMap(confMap map[string]string) (config featureSetConfig, ok bool) {
 	var directionIsHead bool
 	if directionIsHeadStr, ok := confMap["direction_is_head"]; !ok {
@@ -20,13 +20,51 @@ type RandomBinaryTree struct {
 	treeFirst  []int32
 	treeSecond []int32
 
-	// TODO: THESE ARE FOR DEBUGGING AND WILL EVENTUALLY GO AWAY
+	// The above is the "implicit" layout, which needs 2^depth entries however few nodes there
+	// are. In the "compact" layout (see `TrainOptions.Layout`) nodes are stored in the order
+	// they're created, with siblings next to each other, and treeChild[n] is the position of the
+	// left child of internal node n (so its right child is at treeChild[n]+1). treeChild is nil
+	// for the implicit layout. maxDepth is the TreeDepth a compact tree was trained with.
+	treeChild []int32
+	maxDepth  int32
+
+	// TODO: THESE ARE FOR DEBUGGING AND WILL EVENTUALLY GO AWAY (use `Stats` instead)
 	numInternalNodes int32
 	numLeaves        int32
 }
 
 type RandomBinaryForest struct {
 	Trees []RandomBinaryTree
+
+	// The training feature-array the trees index into. We keep a reference (not a copy) so that
+	// queries like `FindNearest` can re-rank candidates. This is not serialized, so a forest read
+	// from a reader needs it re-attached with `WithTrainingData`.
+	featureArray [][]byte
+
+	// The metric used to re-rank candidates (see `WithDistance`); nil means `DefaultDistance`.
+	// Also not serialized.
+	distance Distance
+
+	// Optional human-readable feature names (see `WithFeatureNames`). Also not serialized.
+	featureNames []string
+
+	// The number of rows the forest was trained with, plus any inserted since (deleted rows still
+	// count, since indices aren't reused). Zero if we don't know, e.g. for a forest read from a
+	// reader, since this isn't serialized either.
+	numRows int32
+
+	// Whether featureArray is our own copy that `Insert` can append to, rather than the caller's
+	// slice (which might have spare capacity that appending would write into).
+	ownsFeatureArray bool
+
+	// Set on the forest of a `Classifier` or `Regressor`: their per-leaf data is indexed by tree
+	// number and leaf position, so `Insert`, `Delete`, `Compact` and `SortedByQuality` refuse to
+	// change the trees.
+	frozen bool
+
+	// The options the forest was trained with, which `Insert` uses to split leaves that have grown
+	// too big (see `WithTrainOptions`). Also not serialized.
+	trainOptions TrainOptions
 }
 
 // See comments above (in RandomBinaryTree definition) on ugly bit arithmetic for speed
@@ -58,7 +96,7 @@ func NewTestTree() RandomBinaryTree {
 	rowIndex := []int32{0, 1}
 	treeFirst := []int32{0, high_bit_1 ^ 1, high_bit_1 ^ 0}
 	treeSecond := []int32{1, high_bit_1 ^ 2, high_bit_1 ^ 1}
-	return RandomBinaryTree{rowIndex, treeFirst, treeSecond, 0, 0}
+	return RandomBinaryTree{rowIndex: rowIndex, treeFirst: treeFirst, treeSecond: treeSecond}
 }
 
 func check(e error) {
@@ -0,0 +1,76 @@
+package rbf
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Options for `FindPointsBatch`.
+type BatchOptions struct {
+	// Number of goroutines to spread the queries over. Zero (or negative) means GOMAXPROCS.
+	NumWorkers int
+	// If true, return deduped results (like `FindPointDedupResults`) instead of per-tree results
+	// (like `FindPointAllResults`).
+	Dedup bool
+}
+
+// Results for one query point in a batch. Depending on `BatchOptions.Dedup`, either Count and
+// AllResults are set (same as the return values of `FindPointAllResults`), or DedupResults is
+// set (same as the return value of `FindPointDedupResults`).
+type BatchResult struct {
+	Count        int
+	AllResults   [][]int32
+	DedupResults map[int32]bool
+}
+
+// Query the forest for many points at once, spreading the work over a bounded pool of goroutines.
+// Results are in the same order as the query points.
+//
+// If the context is cancelled the remaining queries are skipped and the context's error is
+// returned along with the (partially filled) results; the results of skipped queries are zero.
+func (forest RandomBinaryForest) FindPointsBatch(ctx context.Context, queryPoints [][]byte, opts BatchOptions) ([]BatchResult, error) {
+	numWorkers := opts.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	if numWorkers > len(queryPoints) {
+		numWorkers = len(queryPoints)
+	}
+
+	results := make([]BatchResult, len(queryPoints))
+	queryNums := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for queryNum := range queryNums {
+				if opts.Dedup {
+					results[queryNum].DedupResults = forest.FindPointDedupResults(queryPoints[queryNum])
+				} else {
+					results[queryNum].Count, results[queryNum].AllResults = forest.FindPointAllResults(queryPoints[queryNum])
+				}
+			}
+		}()
+	}
+
+	// hand out query numbers until we're done or cancelled
+	// (check ctx.Err() first since select picks randomly if a worker is also ready)
+	var err error
+dispatch:
+	for queryNum := range queryPoints {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case queryNums <- queryNum:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		}
+	}
+	close(queryNums)
+	wg.Wait()
+	return results, err
+}
@@ -0,0 +1,50 @@
+package rbf
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFindPointsBatch(t *testing.T) {
+	// given a forest and more queries than workers:
+	points := [][]byte{{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7}}
+	forest := TrainForest(points, 3, 3, 2, 1)
+	queryPoints := [][]byte{{7}, {0}, {3}, {5}, {1}}
+
+	for _, dedup := range []bool{false, true} {
+		// when:
+		results, err := forest.FindPointsBatch(context.Background(), queryPoints, BatchOptions{NumWorkers: 2, Dedup: dedup})
+		// then each result matches the single-point query, in input order:
+		if err != nil {
+			t.Fatalf("FindPointsBatch returned error %v", err)
+		}
+		for i, queryPoint := range queryPoints {
+			var expected BatchResult
+			if dedup {
+				expected.DedupResults = forest.FindPointDedupResults(queryPoint)
+			} else {
+				expected.Count, expected.AllResults = forest.FindPointAllResults(queryPoint)
+			}
+			if !reflect.DeepEqual(results[i], expected) {
+				t.Errorf("dedup == %v, query %d: result == %v; expected %v", dedup, i, results[i], expected)
+			}
+		}
+	}
+}
+
+func TestFindPointsBatchCancelled(t *testing.T) {
+	// given an already-cancelled context:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// when:
+	results, err := forest.FindPointsBatch(ctx, [][]byte{{6, 0}, {0, 0}}, BatchOptions{})
+	// then nothing is queried:
+	if err != context.Canceled {
+		t.Errorf("err == %v; expected %v", err, context.Canceled)
+	}
+	if len(results) != 2 || results[0].AllResults != nil || results[1].AllResults != nil {
+		t.Errorf("results == %v; expected 2 empty results", results)
+	}
+}
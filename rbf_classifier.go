@@ -0,0 +1,265 @@
+package rbf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// How a classifier measures the impurity of a node's labels when choosing splits.
+type ImpurityCriterion int32
+
+const (
+	Gini ImpurityCriterion = iota
+	Entropy
+)
+
+// A forest trained on labelled data: splits are chosen to reduce label impurity, and each leaf
+// remembers how many training rows of each class it has. Those counts can't be kept up to date
+// if the trees change, so `Insert`, `Delete`, `Compact` and `SortedByQuality` panic on the Forest
+// (with ErrFrozenForest).
+type Classifier struct {
+	Forest     RandomBinaryForest
+	NumClasses int32
+
+	// For each tree: the array positions of its leaves (sorted, so we can binary-search them),
+	// and NumClasses counts per leaf (in the same order), flattened.
+	leafPositions   [][]int32
+	leafClassCounts [][]int32
+
+	// The training labels, for `PredictNearest`. Like the forest's training data this is a
+	// reference, and isn't serialized (see `WithLabels`).
+	labels []int32
+}
+
+// Train a classifier. labels[i] is the class of featureArray[i]; classes are numbered from 0, and
+// the number of classes is the largest label + 1. opts.SplitStrategy is ignored (we split on
+// opts.Criterion instead).
+func TrainClassifier(featureArray [][]byte, labels []int32, opts TrainOptions) (Classifier, error) {
+	if err := opts.validate(featureArray); err != nil {
+		return Classifier{}, err
+	}
+	if opts.ColumnMajor {
+		return Classifier{}, fmt.Errorf("rbf: ColumnMajor isn't supported for TrainClassifier")
+	}
+	if len(labels) != len(featureArray) {
+		return Classifier{}, fmt.Errorf("rbf: got %d labels for %d training rows", len(labels), len(featureArray))
+	}
+	var numClasses int32
+	for i, label := range labels {
+		if label < 0 {
+			return Classifier{}, fmt.Errorf("rbf: label %d (row %d) is negative", label, i)
+		}
+		if label >= numClasses {
+			numClasses = label + 1
+		}
+	}
+
+	forest := trainForest(featureArray, opts, treeTrainingParams{labels: labels, numClasses: numClasses, criterion: opts.Criterion})
+	forest.frozen = true
+	classifier := Classifier{Forest: forest, NumClasses: numClasses, labels: labels}
+	for _, tree := range forest.Trees {
+		positions, counts := tree.leafClassDistributions(labels, numClasses)
+		classifier.leafPositions = append(classifier.leafPositions, positions)
+		classifier.leafClassCounts = append(classifier.leafClassCounts, counts)
+	}
+	return classifier, nil
+}
+
+func (tree RandomBinaryTree) leafClassDistributions(labels []int32, numClasses int32) ([]int32, []int32) {
+	positions := tree.sortedLeafPositions()
+	counts := make([]int32, int32(len(positions))*numClasses)
+	for i, arrayPos := range positions {
+		for _, rowNum := range tree.leafIndices(arrayPos) {
+			counts[int32(i)*numClasses+labels[rowNum]] += 1
+		}
+	}
+	return positions, counts
+}
+
+// The probability of each class: the average over trees of the class distribution in the
+// query point's leaf. Trees whose leaf is empty don't vote.
+func (classifier Classifier) PredictProba(point []byte) []float64 {
+	proba := make([]float64, classifier.NumClasses)
+	numVotingTrees := 0
+	for treeNum, tree := range classifier.Forest.Trees {
+		i := leafNumber(classifier.leafPositions[treeNum], tree.findLeaf(point))
+		counts := classifier.leafClassCounts[treeNum][i*classifier.NumClasses : (i+1)*classifier.NumClasses]
+		var total int32
+		for _, count := range counts {
+			total += count
+		}
+		if total == 0 {
+			continue
+		}
+		for class, count := range counts {
+			proba[class] += float64(count) / float64(total)
+		}
+		numVotingTrees += 1
+	}
+	if numVotingTrees > 0 {
+		for class := range proba {
+			proba[class] /= float64(numVotingTrees)
+		}
+	}
+	return proba
+}
+
+// The most probable class (the lowest-numbered one if there's a tie).
+func (classifier Classifier) Predict(point []byte) int32 {
+	return argmaxClass(classifier.PredictProba(point))
+}
+
+// Return a copy of the classifier that uses the given training labels for `PredictNearest`. They
+// must be the ones it was trained with (e.g. after reading it with `ReadClassifierFromReader`).
+func (classifier Classifier) WithLabels(labels []int32) Classifier {
+	classifier.labels = labels
+	return classifier
+}
+
+// Classify by voting among the k nearest training points, as found by `FindNearest` (so this
+// uses the forest's metric; see `WithDistance`). Each neighbor votes for its label with weight
+// 1 / (1 + distance), so closer neighbors count for more. Returns the class with the most weight
+// (the lowest-numbered one if there's a tie, or if there are no neighbors).
+//
+// This needs the training data and labels: `TrainClassifier` attaches both, but a classifier
+// read from a reader needs `Forest.WithTrainingData` and `WithLabels`.
+func (classifier Classifier) PredictNearest(point []byte, k int) (int32, error) {
+	if classifier.labels == nil {
+		return 0, fmt.Errorf("rbf: classifier has no labels attached")
+	}
+	neighbors, err := classifier.Forest.FindNearest(point, k)
+	if err != nil {
+		return 0, err
+	}
+	weights := make([]float64, classifier.NumClasses)
+	for _, neighbor := range neighbors {
+		weights[classifier.labels[neighbor.Index]] += 1 / (1 + neighbor.Distance)
+	}
+	return argmaxClass(weights), nil
+}
+
+// The lowest-numbered class with the highest score.
+func argmaxClass(scores []float64) int32 {
+	var best int32
+	for class, score := range scores {
+		if score > scores[best] {
+			best = int32(class)
+		}
+	}
+	return best
+}
+
+//######################################################################################################################
+// Training: impurity-based splits.
+//######################################################################################################################
+
+// Like `getSingleFeatureFrequencies`, but split per class: the result has numClasses counts for
+// each feature value, i.e. the count for (value, class) is at value*numClasses+class.
+func getSingleFeatureClassFrequencies(rowIndex []int32, featureArray [][]byte, labels []int32, numClasses, featureNum, indexStart, indexEnd int32) []int32 {
+	counts := make([]int32, (max_feature_value+1)*numClasses)
+	for rowNum := indexStart; rowNum < indexEnd; rowNum++ {
+		featureValue := int32(featureArray[rowIndex[rowNum]][featureNum])
+		counts[featureValue*numClasses+labels[rowIndex[rowNum]]] += 1
+	}
+	return counts
+}
+
+// Find the feature and split value that give the lowest total impurity over the two children.
+// Returns: the index into classFrequencies of the best feature, and the split value. If no
+// feature can be split at all we return a degenerate split (and `splitNode` tries again).
+func getLowestImpurityFeature(classFrequencies [][]int32, numClasses int32, criterion ImpurityCriterion) (int32, byte) {
+	var bestFeatureNum int32
+	bestSplitValue := int32(max_feature_value)
+	bestImpurity := math.Inf(1)
+	leftCounts := make([]int32, numClasses)
+	rightCounts := make([]int32, numClasses)
+	for i, counts := range classFrequencies {
+		for class := range leftCounts {
+			leftCounts[class], rightCounts[class] = 0, 0
+		}
+		var totalCount int32
+		for j, count := range counts {
+			rightCounts[int32(j)%numClasses] += count
+			totalCount += count
+		}
+		// Sweep the split value up, moving each value's rows from right to left:
+		var leftCount int32
+		for value := int32(0); value < max_feature_value; value++ {
+			valueCounts := counts[value*numClasses : (value+1)*numClasses]
+			for class, count := range valueCounts {
+				leftCounts[class] += count
+				rightCounts[class] -= count
+				leftCount += count
+			}
+			if leftCount == 0 {
+				continue
+			}
+			if leftCount == totalCount {
+				break
+			}
+			impurity := criterion.impurity(leftCounts, leftCount) + criterion.impurity(rightCounts, totalCount-leftCount)
+			if impurity < bestImpurity {
+				bestImpurity = impurity
+				bestFeatureNum = int32(i)
+				bestSplitValue = value
+			}
+		}
+	}
+	return bestFeatureNum, byte(bestSplitValue)
+}
+
+// The impurity of a node with the given class counts, weighted by the node's size (so the
+// impurities of two children can just be added).
+// - Gini: n * (1 - sum(p^2)) = n - sum(c^2)/n
+// - Entropy: n * -sum(p log p) = n log n - sum(c log c)
+func (criterion ImpurityCriterion) impurity(classCounts []int32, total int32) float64 {
+	n := float64(total)
+	var sum float64
+	switch criterion {
+	case Entropy:
+		for _, count := range classCounts {
+			if count > 0 {
+				sum += float64(count) * math.Log(float64(count))
+			}
+		}
+		return n*math.Log(n) - sum
+	default:
+		for _, count := range classCounts {
+			sum += float64(count) * float64(count)
+		}
+		return n - sum/n
+	}
+}
+
+//######################################################################################################################
+// Read/write.
+//######################################################################################################################
+
+// Write the forest (as `RandomBinaryForest.WriteToWriter` does) followed by the leaf class counts.
+func (classifier Classifier) WriteToWriter(writer io.Writer) {
+	classifier.Forest.WriteToWriter(writer)
+	err := binary.Write(writer, binary.LittleEndian, classifier.NumClasses)
+	check(err)
+	for treeNum := range classifier.Forest.Trees {
+		binary.Write(writer, binary.LittleEndian, int32(len(classifier.leafPositions[treeNum])))
+		unsafelyWriteIntSlice(writer, classifier.leafPositions[treeNum])
+		unsafelyWriteIntSlice(writer, classifier.leafClassCounts[treeNum])
+	}
+}
+
+func ReadClassifierFromReader(reader io.Reader) Classifier {
+	forest := ReadForestFromReader(reader)
+	forest.frozen = true
+	classifier := Classifier{Forest: forest}
+	err := binary.Read(reader, binary.LittleEndian, &classifier.NumClasses)
+	check(err)
+	for range forest.Trees {
+		var numLeaves int32
+		binary.Read(reader, binary.LittleEndian, &numLeaves)
+		classifier.leafPositions = append(classifier.leafPositions, unsafelyReadIntSlice(reader, numLeaves))
+		classifier.leafClassCounts = append(classifier.leafClassCounts, unsafelyReadIntSlice(reader, numLeaves*classifier.NumClasses))
+	}
+	return classifier
+}
@@ -0,0 +1,121 @@
+package rbf
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Two noise features and one feature that determines the class (class = feature 1 / 64).
+func newClassifierTestData() ([][]byte, []int32) {
+	points := newRandomPoints(400, 3, 1, 7)
+	labels := make([]int32, len(points))
+	for i, point := range points {
+		labels[i] = int32(point[1] / 64)
+	}
+	return points, labels
+}
+
+func TestClassifierPredict(t *testing.T) {
+	points, labels := newClassifierTestData()
+	for _, criterion := range []ImpurityCriterion{Gini, Entropy} {
+		// given a classifier trained with each criterion:
+		opts := NewTrainOptions(WithNumTrees(5), WithTreeDepth(8), WithLeafSize(4), WithNumFeaturesToCompare(3),
+			WithSeed(1), WithCriterion(criterion))
+		classifier, err := TrainClassifier(points, labels, opts)
+		if err != nil {
+			t.Fatalf("criterion %d: TrainClassifier returned error %v", criterion, err)
+		}
+		// when we predict points whose class is clear from the informative feature:
+		for _, point := range [][]byte{{200, 10, 50}, {3, 100, 3}, {90, 150, 250}, {0, 250, 0}} {
+			expLabel := int32(point[1] / 64)
+			// then we get that class, with probabilities that add up to 1:
+			if label := classifier.Predict(point); label != expLabel {
+				t.Errorf("criterion %d: Predict(%v) == %d; expected %d", criterion, point, label, expLabel)
+			}
+			proba := classifier.PredictProba(point)
+			var sum float64
+			for _, p := range proba {
+				sum += p
+			}
+			if len(proba) != 4 || math.Abs(sum-1) > 1e-9 {
+				t.Errorf("criterion %d: PredictProba(%v) == %v; expected 4 probabilities adding up to 1", criterion, point, proba)
+			}
+		}
+	}
+}
+
+func TestClassifierSplitsOnInformativeFeature(t *testing.T) {
+	// given class counts for two candidate features, where only the second separates the classes:
+	noise := make([]int32, (max_feature_value+1)*2)
+	noise[10*2+0], noise[10*2+1], noise[20*2+0], noise[20*2+1] = 2, 2, 2, 2
+	informative := make([]int32, (max_feature_value+1)*2)
+	informative[10*2+0], informative[20*2+1] = 4, 4
+	for _, criterion := range []ImpurityCriterion{Gini, Entropy} {
+		// when we look for the lowest-impurity split:
+		featureIndex, splitValue := getLowestImpurityFeature([][]int32{noise, informative}, 2, criterion)
+		// then we split the informative feature between 10 and 20:
+		if featureIndex != 1 || splitValue < 10 || splitValue >= 20 {
+			t.Errorf("criterion %d: (featureIndex, splitValue) == (%d, %d); expected (1, [10, 20))", criterion, featureIndex, splitValue)
+		}
+	}
+}
+
+func TestClassifierPredictNearest(t *testing.T) {
+	// given a classifier whose only leaf has both training points, where the nearer point to the
+	// query depends on the metric (L1: {0, 0, 5} is nearer; Chebyshev: {3, 3, 3} is):
+	points := [][]byte{{3, 3, 3}, {0, 0, 5}}
+	classifier, _ := TrainClassifier(points, []int32{0, 1}, NewTrainOptions(WithNumTrees(1), WithTreeDepth(1)))
+	queryPoint := []byte{0, 0, 0}
+	runOneTest := func(classifier Classifier, description string, k int, expLabel int32) {
+		// when we vote among the nearest points:
+		label, err := classifier.PredictNearest(queryPoint, k)
+		// then the metric decides the vote:
+		if err != nil || label != expLabel {
+			t.Errorf("%s, k == %d: PredictNearest == (%d, %v); expected %d", description, k, label, err, expLabel)
+		}
+	}
+	runOneTest(classifier, "L1", 1, 1)
+	runOneTest(classifier, "L1", 2, 1) // both vote, but the nearer one has more weight
+	classifier.Forest = classifier.Forest.WithDistance(Chebyshev)
+	runOneTest(classifier, "Chebyshev", 1, 0)
+	runOneTest(classifier, "Chebyshev", 2, 0)
+
+	// and without labels it's an error:
+	if _, err := classifier.WithLabels(nil).PredictNearest(queryPoint, 1); err == nil {
+		t.Errorf("expected an error without labels")
+	}
+}
+
+func TestClassifierErrors(t *testing.T) {
+	points := [][]byte{{0}, {1}}
+	runOneTest := func(labels []int32, opts TrainOptions, expErrSubstring string) {
+		_, err := TrainClassifier(points, labels, opts)
+		if err == nil || !strings.Contains(err.Error(), expErrSubstring) {
+			t.Errorf("err == %v; expected error containing %q", err, expErrSubstring)
+		}
+	}
+	runOneTest([]int32{0}, NewTrainOptions(), "labels")
+	runOneTest([]int32{0, -1}, NewTrainOptions(), "negative")
+	runOneTest([]int32{0, 1}, NewTrainOptions(WithCriterion(7)), "Criterion")
+}
+
+func TestReadAndWriteClassifier(t *testing.T) {
+	// given a trained classifier:
+	points, labels := newClassifierTestData()
+	classifier, _ := TrainClassifier(points, labels, NewTrainOptions(WithNumTrees(3), WithTreeDepth(6), WithLeafSize(8)))
+	// when we write it and read it back:
+	var builder strings.Builder
+	classifier.WriteToWriter(&builder)
+	classifierIn := ReadClassifierFromReader(strings.NewReader(builder.String()))
+	// then it makes the same predictions:
+	if !reflect.DeepEqual(classifierIn.leafClassCounts, classifier.leafClassCounts) || classifierIn.NumClasses != classifier.NumClasses {
+		t.Errorf("deserialized classifier not the same as original classifier")
+	}
+	for _, point := range points[:20] {
+		if !reflect.DeepEqual(classifierIn.PredictProba(point), classifier.PredictProba(point)) {
+			t.Errorf("PredictProba(%v) changed after serialization", point)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rbf
+
+import (
+	"fmt"
+	"math"
+)
+
+// Training data stored column by column: feature j of row i is Data[j*NumRows+i].
+//
+// Computing a split histogram reads one feature of every row at the node. With the usual
+// row-major [][]byte every one of those reads is in a different row slice, which thrashes the
+// cache on wide data (e.g. 1369 bigram features). In a ColumnMatrix they're all in the same
+// NumRows-byte column.
+type ColumnMatrix struct {
+	NumRows     int
+	NumFeatures int
+	Data        []byte
+}
+
+// Transpose row-major training data. Pre-req: the rows all have the same length.
+func NewColumnMatrix(featureArray [][]byte) ColumnMatrix {
+	columns := ColumnMatrix{NumRows: len(featureArray)}
+	if len(featureArray) > 0 {
+		columns.NumFeatures = len(featureArray[0])
+	}
+	columns.Data = make([]byte, columns.NumRows*columns.NumFeatures)
+	// Go a block of rows at a time so that we write to each column sequentially but don't
+	// have to read the rows more than once.
+	const blockSize = 64
+	for blockStart := 0; blockStart < columns.NumRows; blockStart += blockSize {
+		blockEnd := blockStart + blockSize
+		if blockEnd > columns.NumRows {
+			blockEnd = columns.NumRows
+		}
+		for featureNum := 0; featureNum < columns.NumFeatures; featureNum++ {
+			column := columns.Data[featureNum*columns.NumRows : (featureNum+1)*columns.NumRows]
+			for rowNum := blockStart; rowNum < blockEnd; rowNum++ {
+				column[rowNum] = featureArray[rowNum][featureNum]
+			}
+		}
+	}
+	return columns
+}
+
+// One feature's values for all rows. This is a view into Data.
+func (columns ColumnMatrix) Column(featureNum int32) []byte {
+	return columns.Data[int(featureNum)*columns.NumRows : (int(featureNum)+1)*columns.NumRows]
+}
+
+// Train a forest from column-major data (e.g. if that's how you have it already, so there's no
+// need for a row-major copy). The forest doesn't have its training data attached (see
+// `WithTrainingData`), since that has to be row-major. opts.ColumnMajor is ignored.
+func TrainForestFromColumns(columns ColumnMatrix, opts TrainOptions) (RandomBinaryForest, error) {
+	if columns.NumRows == 0 {
+		return RandomBinaryForest{}, fmt.Errorf("rbf: no training data")
+	}
+	if columns.NumRows > math.MaxInt32 {
+		return RandomBinaryForest{}, fmt.Errorf("rbf: too much training data (%d rows; at most %d allowed)", columns.NumRows, math.MaxInt32)
+	}
+	if columns.NumFeatures == 0 {
+		return RandomBinaryForest{}, fmt.Errorf("rbf: training data has no features")
+	}
+	if len(columns.Data) != columns.NumRows*columns.NumFeatures {
+		return RandomBinaryForest{}, fmt.Errorf("rbf: ColumnMatrix has %d bytes of data; expected %d rows x %d features",
+			len(columns.Data), columns.NumRows, columns.NumFeatures)
+	}
+	if err := opts.validateParams(columns.NumFeatures); err != nil {
+		return RandomBinaryForest{}, err
+	}
+	return trainForest(nil, opts, treeTrainingParams{columns: &columns}), nil
+}
+
+// Same as `getSingleFeatureFrequencies`, but for one column of a ColumnMatrix.
+func getSingleColumnFrequencies(rowIndex []int32, column []byte, indexStart, indexEnd int32) ([]int32, int32) {
+	counts := make([]int32, max_feature_value+1)
+	var weightedTotal int32 = 0
+	for _, rowNum := range rowIndex[indexStart:indexEnd] {
+		featureValue := column[rowNum]
+		counts[featureValue] += 1
+		weightedTotal += int32(featureValue)
+	}
+	return counts, weightedTotal
+}
+
+// Like `quickPartition`, but for one column of a ColumnMatrix, and keeping the rows on each side
+// in the order they were in. The root's rows are in increasing order, so every node's are too,
+// and histograms read each column front to back instead of jumping around it. scratch has to
+// have room for the rows that go right.
+func stablePartitionColumn(rowIndex, scratch []int32, column []byte, indexStart, indexEnd int32, splitValue byte) int32 {
+	indexSplit := indexStart
+	numRight := 0
+	for _, rowNum := range rowIndex[indexStart:indexEnd] {
+		if column[rowNum] <= splitValue {
+			rowIndex[indexSplit] = rowNum
+			indexSplit += 1
+		} else {
+			scratch[numRight] = rowNum
+			numRight += 1
+		}
+	}
+	copy(rowIndex[indexSplit:indexEnd], scratch[:numRight])
+	return indexSplit
+}
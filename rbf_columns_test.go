@@ -0,0 +1,146 @@
+package rbf
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNewColumnMatrix(t *testing.T) {
+	// given row-major data with more rows than a transpose block:
+	points := make([][]byte, 100)
+	for i := range points {
+		points[i] = []byte{byte(i), byte(2 * i), byte(3 * i)}
+	}
+	// when we transpose it:
+	columns := NewColumnMatrix(points)
+	// then each column has that feature for every row:
+	if columns.NumRows != 100 || columns.NumFeatures != 3 || len(columns.Data) != 300 {
+		t.Fatalf("columns are %d x %d (%d bytes); expected 100 x 3 (300 bytes)", columns.NumRows, columns.NumFeatures, len(columns.Data))
+	}
+	for featureNum := int32(0); featureNum < 3; featureNum++ {
+		for i, value := range columns.Column(featureNum) {
+			if value != points[i][featureNum] {
+				t.Errorf("Column(%d)[%d] == %d; expected %d", featureNum, i, value, points[i][featureNum])
+			}
+		}
+	}
+}
+
+func TestColumnMajorTrainingMatchesRowMajor(t *testing.T) {
+	// given some data:
+	points := newRandomPoints(500, 40, 0.2, 1)
+	opts := NewTrainOptions(WithNumTrees(4), WithTreeDepth(8), WithLeafSize(4), WithSeed(7))
+	rowMajor, _ := TrainForestWithOptions(points, opts)
+	// when we train column-major, either by asking for it or by passing columns:
+	opts.ColumnMajor = true
+	columnMajor, err1 := TrainForestWithOptions(points, opts)
+	fromColumns, err2 := TrainForestFromColumns(NewColumnMatrix(points), opts)
+	// then we get the same trees, with the same rows in each leaf (but maybe in a different order):
+	if err1 != nil || err2 != nil {
+		t.Fatalf("errors: %v, %v", err1, err2)
+	}
+	sortedLeaves := func(tree RandomBinaryTree) [][]int32 {
+		var leaves [][]int32
+		tree.forEachLeaf(func(arrayPos int32) {
+			leaf := append([]int32(nil), tree.leafIndices(arrayPos)...)
+			sort.Slice(leaf, func(i, j int) bool { return leaf[i] < leaf[j] })
+			leaves = append(leaves, leaf)
+		})
+		return leaves
+	}
+	for treeNum, tree := range rowMajor.Trees {
+		for _, other := range []RandomBinaryTree{columnMajor.Trees[treeNum], fromColumns.Trees[treeNum]} {
+			if !reflect.DeepEqual(other.treeFirst, tree.treeFirst) || !reflect.DeepEqual(other.treeSecond, tree.treeSecond) ||
+				!reflect.DeepEqual(sortedLeaves(other), sortedLeaves(tree)) {
+				t.Errorf("tree %d: column-major training gave a different tree from row-major training", treeNum)
+			}
+		}
+	}
+	if !reflect.DeepEqual(columnMajor.featureArray, points) || fromColumns.featureArray != nil {
+		t.Errorf("expected the training data attached to the first forest only")
+	}
+}
+
+func TestStablePartitionColumn(t *testing.T) {
+	// given rows in increasing order:
+	column := []byte{5, 1, 9, 2, 7, 3}
+	rowIndex := []int32{0, 1, 2, 3, 4, 5}
+	// when we partition them:
+	indexSplit := stablePartitionColumn(rowIndex, make([]int32, len(rowIndex)), column, 0, 6, 4)
+	// then both sides are still in increasing order:
+	if expected := []int32{1, 3, 5, 0, 2, 4}; indexSplit != 3 || !reflect.DeepEqual(rowIndex, expected) {
+		t.Errorf("rowIndex == %v, split %d; expected %v, split 3", rowIndex, indexSplit, expected)
+	}
+}
+
+func TestColumnMajorIsRejectedForClassifierAndRegressor(t *testing.T) {
+	points := [][]byte{{0, 0}, {255, 255}}
+	opts := NewTrainOptions(WithColumnMajor(true))
+	if _, err := TrainClassifier(points, []int32{0, 1}, opts); err == nil {
+		t.Errorf("expected TrainClassifier to reject ColumnMajor")
+	}
+	if _, err := TrainRegressor(points, []float64{0, 1}, opts); err == nil {
+		t.Errorf("expected TrainRegressor to reject ColumnMajor")
+	}
+}
+
+func TestTrainForestFromColumnsErrors(t *testing.T) {
+	runOneTest := func(columns ColumnMatrix, expErrSubstring string) {
+		_, err := TrainForestFromColumns(columns, NewTrainOptions())
+		if err == nil || !strings.Contains(err.Error(), expErrSubstring) {
+			t.Errorf("err == %v; expected error containing %q", err, expErrSubstring)
+		}
+	}
+	runOneTest(ColumnMatrix{}, "no training data")
+	runOneTest(ColumnMatrix{NumRows: 2}, "no features")
+	runOneTest(ColumnMatrix{NumRows: 2, NumFeatures: 2, Data: []byte{1, 2, 3}}, "expected 2 rows x 2 features")
+}
+
+func benchmarkTrain(b *testing.B, columnMajor bool) {
+	points := newRandomPoints(60000, 784, 0.2, 1)
+	opts := NewTrainOptions(WithNumTrees(1), WithTreeDepth(20), WithLeafSize(32), WithSeed(1), WithColumnMajor(columnMajor))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		TrainForestWithOptions(points, opts)
+	}
+}
+
+// Train one tree on mnist-sized data (60000 x 784), row-major vs column-major (including the
+// transpose).
+func BenchmarkTrainRowMajor(b *testing.B)    { benchmarkTrain(b, false) }
+func BenchmarkTrainColumnMajor(b *testing.B) { benchmarkTrain(b, true) }
+
+// Just the histograms, over all rows in a shuffled order (as row-major training has them below
+// the root; column-major training keeps them in order, see `stablePartitionColumn`).
+func benchmarkFeatureFrequencies(b *testing.B, columnMajor, shuffled bool) {
+	points := newRandomPoints(60000, 784, 0.2, 1)
+	columns := NewColumnMatrix(points)
+	rowIndex := make([]int32, len(points))
+	for i := range rowIndex {
+		rowIndex[i] = int32(i)
+	}
+	if shuffled {
+		rand.New(rand.NewSource(1)).Shuffle(len(rowIndex), func(i, j int) { rowIndex[i], rowIndex[j] = rowIndex[j], rowIndex[i] })
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var weightedTotal int32
+		if columnMajor {
+			_, weightedTotal = getSingleColumnFrequencies(rowIndex, columns.Column(int32(n%784)), 0, int32(len(rowIndex)))
+		} else {
+			_, weightedTotal = getSingleFeatureFrequencies(rowIndex, points, int32(n%784), 0, int32(len(rowIndex)))
+		}
+		result += weightedTotal
+	}
+}
+
+func BenchmarkFeatureFrequenciesRowMajor(b *testing.B) { benchmarkFeatureFrequencies(b, false, true) }
+func BenchmarkFeatureFrequenciesColumnMajorShuffled(b *testing.B) {
+	benchmarkFeatureFrequencies(b, true, true)
+}
+func BenchmarkFeatureFrequenciesColumnMajor(b *testing.B) {
+	benchmarkFeatureFrequencies(b, true, false)
+}
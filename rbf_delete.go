@@ -0,0 +1,92 @@
+package rbf
+
+// Remove a point from the forest so that no query returns it. Indices aren't reused, so other
+// points keep their indices (and `Insert` keeps numbering from the end of the training data).
+//
+// In each tree we find the point's leaf, swap the point to the end of the leaf's view into
+// rowIndex, and shrink the view by one. The point's slot is left behind in rowIndex as a
+// tombstone until `Compact` removes it. If the training data is attached (see
+// `WithTrainingData`) we find the leaf by routing the point's features down the tree, and only
+// if the point isn't there (which shouldn't happen, but a deletion must not silently miss) do we
+// look through every leaf. Without the training data we always have to look through every leaf
+// of every tree, so each call takes time proportional to (number of trees) x (number of training
+// rows); attach the training data before deleting more than a few points.
+//
+// Deleting a point that isn't in the forest (e.g. deleting it twice) does nothing, though it
+// costs a full scan. Note that the
+// point's features are still in the training data. Like `Insert`, Delete isn't safe to call
+// concurrently with queries, and panics with ErrFrozenForest on a `Classifier` or `Regressor`'s
+// forest.
+func (forest *RandomBinaryForest) Delete(index int32) {
+	if forest.frozen {
+		panic(ErrFrozenForest)
+	}
+	for treeNum := range forest.Trees {
+		tree := &forest.Trees[treeNum]
+		if int(index) < len(forest.featureArray) && tree.removeFromLeaf(tree.findLeaf(forest.featureArray[index]), index) {
+			continue
+		}
+		tree.forEachLeaf(func(arrayPos int32) { tree.removeFromLeaf(arrayPos, index) })
+	}
+}
+
+// Remove a row from a leaf if it's there. Returns whether it was.
+func (tree *RandomBinaryTree) removeFromLeaf(arrayPos, index int32) bool {
+	indexStart, indexEnd := tree.leafRange(arrayPos)
+	for i := indexStart; i < indexEnd; i++ {
+		if tree.rowIndex[i] == index {
+			tree.rowIndex[i], tree.rowIndex[indexEnd-1] = tree.rowIndex[indexEnd-1], tree.rowIndex[i]
+			tree.setLeaf(arrayPos, indexStart, indexEnd-1)
+			return true
+		}
+	}
+	return false
+}
+
+// Reclaim space after `Delete` and `Insert`: rewrite every tree's rowIndex without tombstones
+// (or the unused slots `Insert` leaves behind), and merge sibling leaves that together have
+// fewer than LeafSize rows (see `WithTrainOptions`) back into their parent.
+//
+// Query results are the same before and after, apart from merged leaves returning their
+// siblings' rows too. Points in merged leaves reach the parent instead, so their signatures from
+// `Encode` change. Panics with ErrFrozenForest on a `Classifier` or `Regressor`'s forest.
+func (forest *RandomBinaryForest) Compact() {
+	if forest.frozen {
+		panic(ErrFrozenForest)
+	}
+	leafSize := forest.trainOptions.LeafSize
+	if leafSize == 0 {
+		leafSize = default_leaf_size
+	}
+	for treeNum := range forest.Trees {
+		tree := &forest.Trees[treeNum]
+		tree.packRowIndex()
+		tree.mergeSmallLeaves(leafSize)
+	}
+}
+
+// Merge (bottom-up) pairs of sibling leaves with fewer than leafSize rows between them.
+// Pre-req: rowIndex is packed (see `packRowIndex`), so siblings' views are adjacent.
+func (tree *RandomBinaryTree) mergeSmallLeaves(leafSize int32) {
+	var visit func(arrayPos int32)
+	visit = func(arrayPos int32) {
+		if tree.isLeaf(arrayPos) {
+			return
+		}
+		left, right := tree.children(arrayPos)
+		visit(left)
+		visit(right)
+		if !tree.isLeaf(left) || !tree.isLeaf(right) {
+			return
+		}
+		leftStart, leftEnd := tree.leafRange(left)
+		rightStart, rightEnd := tree.leafRange(right)
+		if leftEnd == rightStart && rightEnd-leftStart < leafSize {
+			tree.setLeaf(arrayPos, leftStart, rightEnd)
+			// TODO: remove numLeaves and numInternalNodes
+			tree.numLeaves -= 1
+			tree.numInternalNodes -= 1
+		}
+	}
+	visit(0)
+}
@@ -0,0 +1,121 @@
+package rbf
+
+import (
+	"testing"
+)
+
+func newDeleteTestForest() (RandomBinaryForest, [][]byte) {
+	points := newRandomPoints(200, 4, 1, 5)
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(3), WithTreeDepth(8), WithLeafSize(8), WithSeed(1)))
+	return forest, points
+}
+
+func TestDelete(t *testing.T) {
+	runOneTest := func(forest RandomBinaryForest, points [][]byte, description string) {
+		// when we delete every other point (some of them twice):
+		for i := int32(0); i < int32(len(points)); i += 2 {
+			forest.Delete(i)
+		}
+		forest.Delete(0)
+		// then no query returns deleted points, and the others still find themselves:
+		for i, point := range points {
+			results := forest.FindPointDedupResults(point)
+			for index := range results {
+				if index%2 == 0 {
+					t.Errorf("%s: query for point %d returned deleted point %d", description, i, index)
+				}
+			}
+			if i%2 == 1 && !results[int32(i)] {
+				t.Errorf("%s: point %d didn't find itself", description, i)
+			}
+		}
+		// and deleted points are in no tree's leaves at all:
+		for treeNum, tree := range forest.Trees {
+			tree.forEachLeaf(func(arrayPos int32) {
+				for _, index := range tree.leafIndices(arrayPos) {
+					if index%2 == 0 {
+						t.Errorf("%s: tree %d still has deleted point %d", description, treeNum, index)
+					}
+				}
+			})
+		}
+	}
+
+	// given forests with and without their training data attached:
+	forest, points := newDeleteTestForest()
+	runOneTest(forest, points, "with training data")
+	forest, points = newDeleteTestForest()
+	runOneTest(RandomBinaryForest{Trees: forest.Trees}, points, "without training data")
+}
+
+func TestDeleteFindsRowsThatAreNotWhereTheyRoute(t *testing.T) {
+	// given a tree with row 0 in its right leaf, but training data that routes row 0 left:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	forest = forest.WithTrainingData([][]byte{{0, 0, 0}, {0, 0, 0}})
+	// when we delete row 0:
+	forest.Delete(0)
+	// then it's gone anyway:
+	tree := forest.Trees[0]
+	if left, right := tree.leafIndices(1), tree.leafIndices(2); len(left) != 1 || len(right) != 0 {
+		t.Errorf("leaves == %v, %v; expected [1], []", left, right)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	// given a forest with most of its points deleted:
+	forest, points := newDeleteTestForest()
+	numLeavesBefore := forest.Trees[0].numLeaves
+	for i := int32(0); i < int32(len(points)); i++ {
+		if i%10 != 0 {
+			forest.Delete(i)
+		}
+	}
+	// when we compact it:
+	forest.Compact()
+	for treeNum, tree := range forest.Trees {
+		// then the tombstones are gone from rowIndex:
+		if len(tree.rowIndex) != len(points)/10 {
+			t.Errorf("tree %d: len(rowIndex) == %d; expected %d", treeNum, len(tree.rowIndex), len(points)/10)
+		}
+		// and small leaves have been merged:
+		if tree.numLeaves >= numLeavesBefore {
+			t.Errorf("tree %d: %d leaves after compacting; expected fewer than %d", treeNum, tree.numLeaves, numLeavesBefore)
+		}
+	}
+	// and the remaining points still find themselves (and nothing deleted):
+	for i := 0; i < len(points); i += 10 {
+		results := forest.FindPointDedupResults(points[i])
+		if !results[int32(i)] {
+			t.Errorf("point %d didn't find itself", i)
+		}
+		for index := range results {
+			if index%10 != 0 {
+				t.Errorf("query for point %d returned deleted point %d", i, index)
+			}
+		}
+	}
+	// and we can keep inserting:
+	if index := forest.Insert(points[1]); index != int32(len(points)) || !forest.FindPointDedupResults(points[1])[index] {
+		t.Errorf("point inserted after compacting (index %d) didn't find itself", index)
+	}
+}
+
+func TestProximityMatrixSkipsDeletedPoints(t *testing.T) {
+	// given a forest with its last point deleted:
+	forest, points := newDeleteTestForest()
+	forest.Delete(int32(len(points) - 1))
+	forest.Delete(0)
+	// when we build the proximity matrix:
+	matrix := forest.ProximityMatrix(0)
+	// then the deleted points have no neighbors and aren't anyone's neighbor:
+	if len(matrix[0]) != 0 {
+		t.Errorf("deleted point 0 has %d neighbors; expected none", len(matrix[0]))
+	}
+	for row, entries := range matrix {
+		for _, entry := range entries {
+			if entry.Index == 0 || entry.Index == int32(len(points)-1) {
+				t.Errorf("row %d has deleted point %d as a neighbor", row, entry.Index)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package rbf
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// A distance metric between two feature-arrays, used to re-rank the candidates that the trees
+// return (in `FindNearest`, `FindWithinRadius` and `Classifier.PredictNearest`). The trees
+// themselves don't use a metric (see "Median-splitting pros and cons" in the README); this is
+// only applied to the (much smaller) candidate sets.
+//
+// Implementations must be safe for concurrent use and may assume both arguments have the same length.
+type Distance interface {
+	Distance(v1, v2 []byte) float64
+}
+
+// Adapter to allow the use of an ordinary function as a Distance.
+type DistanceFunc func(v1, v2 []byte) float64
+
+func (f DistanceFunc) Distance(v1, v2 []byte) float64 {
+	return f(v1, v2)
+}
+
+// A metric that never decreases when the difference in any one feature grows (e.g. the Lp metrics,
+// but not cosine). Range queries can use this to prune whole subtrees: the distance from the query
+// to the closest corner of a subtree's bounding box is a lower bound for every point in it.
+type MonotoneDistance interface {
+	Distance
+	Monotone()
+}
+
+// Adapter like DistanceFunc, for functions that satisfy MonotoneDistance's promise.
+type MonotoneDistanceFunc func(v1, v2 []byte) float64
+
+func (f MonotoneDistanceFunc) Distance(v1, v2 []byte) float64 {
+	return f(v1, v2)
+}
+
+func (f MonotoneDistanceFunc) Monotone() {}
+
+// Built-in metrics. All arithmetic is done in ints or floats, never bytes, so nothing wraps around.
+var (
+	L1        Distance = MonotoneDistanceFunc(func(v1, v2 []byte) float64 { return float64(l1Dist(v1, v2)) })
+	L2        Distance = MonotoneDistanceFunc(func(v1, v2 []byte) float64 { return math.Sqrt(float64(l2DistSquared(v1, v2))) })
+	Hamming   Distance = MonotoneDistanceFunc(hammingDist)
+	Chebyshev Distance = MonotoneDistanceFunc(chebyshevDist)
+	Cosine    Distance = DistanceFunc(cosineDist)
+)
+
+// The metric used when none has been set on the forest.
+var DefaultDistance = L1
+
+//######################################################################################################################
+// Registry, so metrics can be selected by name (e.g. from a config file).
+//######################################################################################################################
+var distancesMu sync.RWMutex
+var distances = map[string]Distance{
+	"l1":        L1,
+	"l2":        L2,
+	"hamming":   Hamming,
+	"chebyshev": Chebyshev,
+	"cosine":    Cosine,
+}
+
+// Make a metric available by name. Like `sql.Register`, this panics if the name is already taken
+// or the metric is nil, since that's a programming error; call it from an `init` function.
+func RegisterDistance(name string, distance Distance) {
+	distancesMu.Lock()
+	defer distancesMu.Unlock()
+	if distance == nil {
+		panic("rbf: RegisterDistance called with nil distance for " + name)
+	}
+	if _, dup := distances[name]; dup {
+		panic("rbf: RegisterDistance called twice for " + name)
+	}
+	distances[name] = distance
+}
+
+// Get a built-in or registered metric by name.
+func LookupDistance(name string) (Distance, error) {
+	distancesMu.RLock()
+	defer distancesMu.RUnlock()
+	if distance, ok := distances[name]; ok {
+		return distance, nil
+	}
+	return nil, fmt.Errorf("rbf: unknown distance %q", name)
+}
+
+//######################################################################################################################
+
+// Return a copy of the forest that re-ranks candidates using the given metric.
+func (forest RandomBinaryForest) WithDistance(distance Distance) RandomBinaryForest {
+	forest.distance = distance
+	return forest
+}
+
+// The metric this forest re-ranks with.
+func (forest RandomBinaryForest) metric() Distance {
+	if forest.distance == nil {
+		return DefaultDistance
+	}
+	return forest.distance
+}
+
+// Manhattan distance. Do the subtraction in ints so bytes don't wrap around.
+func l1Dist(v1, v2 []byte) int {
+	dist := 0
+	for i := range v1 {
+		diff := int(v1[i]) - int(v2[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		dist += diff
+	}
+	return dist
+}
+
+func l2DistSquared(v1, v2 []byte) int {
+	distSquared := 0
+	for i := range v1 {
+		diff := int(v1[i]) - int(v2[i])
+		distSquared += diff * diff
+	}
+	return distSquared
+}
+
+// Number of features that differ.
+func hammingDist(v1, v2 []byte) float64 {
+	dist := 0
+	for i := range v1 {
+		if v1[i] != v2[i] {
+			dist += 1
+		}
+	}
+	return float64(dist)
+}
+
+// Largest difference over all features.
+func chebyshevDist(v1, v2 []byte) float64 {
+	maxDiff := 0
+	for i := range v1 {
+		diff := int(v1[i]) - int(v2[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > maxDiff {
+			maxDiff = diff
+		}
+	}
+	return float64(maxDiff)
+}
+
+// 1 - cosine similarity, so it's in [0, 1] for our non-negative features. The zero vector has no
+// direction, so we call it identical to itself and maximally far from everything else.
+func cosineDist(v1, v2 []byte) float64 {
+	var dot, norm1, norm2 int
+	for i := range v1 {
+		dot += int(v1[i]) * int(v2[i])
+		norm1 += int(v1[i]) * int(v1[i])
+		norm2 += int(v2[i]) * int(v2[i])
+	}
+	if norm1 == 0 || norm2 == 0 {
+		if norm1 == norm2 {
+			return 0
+		}
+		return 1
+	}
+	return 1 - (float64(dot) / math.Sqrt(float64(norm1)*float64(norm2)))
+}
@@ -0,0 +1,57 @@
+package rbf
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBuiltInDistances(t *testing.T) {
+	// given two points whose byte-differences would wrap around if done in bytes:
+	v1 := []byte{0, 3, 255, 7}
+	v2 := []byte{4, 0, 0, 7}
+	runOneTest := func(name string, distance Distance, expected float64) {
+		if actual := distance.Distance(v1, v2); math.Abs(actual-expected) > 1e-9 {
+			t.Errorf("%s distance == %f; expected %f", name, actual, expected)
+		}
+	}
+	runOneTest("L1", L1, 262)                     // 4 + 3 + 255 + 0
+	runOneTest("L2", L2, math.Sqrt(16+9+255*255)) // no overflow
+	runOneTest("Hamming", Hamming, 3)             // last feature matches
+	runOneTest("Chebyshev", Chebyshev, 255)       // biggest single difference
+	runOneTest("Cosine", Cosine, 1-(49/math.Sqrt(float64(9+255*255+49)*65)))
+	if zeroDist := Cosine.Distance([]byte{0, 0}, []byte{0, 0}); zeroDist != 0 {
+		t.Errorf("Cosine distance between zero vectors == %f; expected 0", zeroDist)
+	}
+}
+
+func TestRegisterDistance(t *testing.T) {
+	// given a custom metric registered by name:
+	alwaysOne := DistanceFunc(func(v1, v2 []byte) float64 { return 1 })
+	RegisterDistance("test_always_one", alwaysOne)
+	// when we look it up, then we get it back:
+	if distance, err := LookupDistance("test_always_one"); err != nil || distance.Distance(nil, nil) != 1 {
+		t.Errorf("LookupDistance(test_always_one) == (%v, %v)", distance, err)
+	}
+	// and built-ins are there too but unknown names aren't:
+	if _, err := LookupDistance("l2"); err != nil {
+		t.Errorf("LookupDistance(l2) returned error %v", err)
+	}
+	if _, err := LookupDistance("no_such_metric"); err == nil {
+		t.Errorf("LookupDistance(no_such_metric) didn't return an error")
+	}
+}
+
+func TestFindNearestUsesForestDistance(t *testing.T) {
+	// given a single-leaf forest where L1 and Chebyshev disagree on the nearest point:
+	points := [][]byte{{3, 3}, {0, 5}}
+	forest := TrainForest(points, 1, 1, 1, 1)
+	queryPoint := []byte{0, 0}
+	// when we re-rank with Chebyshev:
+	neighbors, _ := forest.WithDistance(Chebyshev).FindNearest(queryPoint, 1)
+	// then {3, 3} wins (L1 would have picked {0, 5}):
+	expected := []Neighbor{{0, 3}}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("neighbors == %v; expected %v", neighbors, expected)
+	}
+}
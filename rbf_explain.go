@@ -0,0 +1,73 @@
+package rbf
+
+import (
+	"fmt"
+)
+
+// One internal node on a query's path through a tree.
+type ExplainStep struct {
+	FeatureNum  int32
+	FeatureName string // empty unless the forest has feature names (see `WithFeatureNames`)
+	SplitValue  int32
+	QueryValue  byte
+	WentLeft    bool // i.e. QueryValue <= SplitValue
+}
+
+// A query's path through one tree, and the leaf it ended up in.
+type TreeExplanation struct {
+	Path     []ExplainStep
+	LeafPos  int32 // position of the leaf in the tree arrays (same as in `Encode`, with the same caveats)
+	LeafSize int
+}
+
+// Return a copy of the forest that uses the given human-readable feature names (e.g. from
+// `features.CreateFeatureNames`) in explanations and reports. names[i] is the name of feature i.
+func (forest RandomBinaryForest) WithFeatureNames(names []string) RandomBinaryForest {
+	forest.featureNames = names
+	return forest
+}
+
+// The name of a feature if we have one, otherwise "".
+func (forest RandomBinaryForest) featureName(featureNum int32) string {
+	if int(featureNum) < len(forest.featureNames) {
+		return forest.featureNames[featureNum]
+	}
+	return ""
+}
+
+// Explain a query: for each tree, the path the query point took (every split it went through,
+// and which way it went) and the size of the leaf it ended up in. Useful for figuring out why a
+// match looks wrong.
+func (forest RandomBinaryForest) Explain(queryPoint []byte) []TreeExplanation {
+	explanations := make([]TreeExplanation, len(forest.Trees))
+	for i, tree := range forest.Trees {
+		var path []ExplainStep
+		arrayPos := int32(0)
+		for !tree.isLeaf(arrayPos) {
+			featureNum, splitValue := tree.split(arrayPos)
+			queryValue := queryPoint[featureNum]
+			wentLeft := int32(queryValue) <= splitValue
+			path = append(path, ExplainStep{featureNum, forest.featureName(featureNum), splitValue, queryValue, wentLeft})
+			left, right := tree.children(arrayPos)
+			if wentLeft {
+				arrayPos = left
+			} else {
+				arrayPos = right
+			}
+		}
+		explanations[i] = TreeExplanation{path, arrayPos, len(tree.leafIndices(arrayPos))}
+	}
+	return explanations
+}
+
+// e.g. `feature 39 "bigrams:bc": 3 > 1, went right`
+func (step ExplainStep) String() string {
+	feature := fmt.Sprintf("feature %d", step.FeatureNum)
+	if step.FeatureName != "" {
+		feature = fmt.Sprintf("%s %q", feature, step.FeatureName)
+	}
+	if step.WentLeft {
+		return fmt.Sprintf("%s: %d <= %d, went left", feature, step.QueryValue, step.SplitValue)
+	}
+	return fmt.Sprintf("%s: %d > %d, went right", feature, step.QueryValue, step.SplitValue)
+}
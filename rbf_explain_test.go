@@ -0,0 +1,26 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	// given the test tree, with feature names:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}.WithFeatureNames([]string{"aa", "ab"})
+	queryPoint := []byte{6, 0, 0, 0, 0, 0}
+	// when:
+	explanations := forest.Explain(queryPoint)
+	// then we see the one split (on "aa") and the leaf with "aaa" in it:
+	expected := []TreeExplanation{{
+		Path:     []ExplainStep{{FeatureNum: 0, FeatureName: "aa", SplitValue: 1, QueryValue: 6, WentLeft: false}},
+		LeafPos:  2,
+		LeafSize: 1,
+	}}
+	if !reflect.DeepEqual(explanations, expected) {
+		t.Errorf("explanations == %v; expected %v", explanations, expected)
+	}
+	if s := explanations[0].Path[0].String(); s != `feature 0 "aa": 6 > 1, went right` {
+		t.Errorf("step.String() == %s", s)
+	}
+}
@@ -0,0 +1,153 @@
+package rbf
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// How much the forest relies on one feature.
+type FeatureImportance struct {
+	FeatureNum  int32
+	FeatureName string // empty unless the forest has feature names (see `WithFeatureNames`)
+	// Number of splits on this feature, over all trees.
+	NumSplits int
+	// SplitDepths[d] is the number of those splits at depth d (the root is at depth 0). Splits
+	// near the root affect every query; splits near the leaves only affect a few.
+	SplitDepths []int
+	MeanDepth   float64
+	// The average over this feature's splits of (rows on the smaller side) / (rows at the node),
+	// as in `TreeStats.MeanSplitBalance`.
+	MeanBalance float64
+	// Only if a validation set was given (otherwise NaN): how much of a validation point's
+	// candidate set (as in `FindPointDedupResults`) it loses when this feature's value is swapped
+	// for another validation point's. 0 means the feature doesn't matter; 1 means none of the
+	// original candidates are found any more.
+	RecallImpact float64
+}
+
+func (importance FeatureImportance) String() string {
+	name := importance.FeatureName
+	if name == "" {
+		name = fmt.Sprintf("feature %d", importance.FeatureNum)
+	}
+	return fmt.Sprintf("%s: %d splits, mean depth %.2f, mean balance %.2f, recall impact %.3f",
+		name, importance.NumSplits, importance.MeanDepth, importance.MeanBalance, importance.RecallImpact)
+}
+
+type ImportanceOptions struct {
+	// Optional: points (not necessarily from the training set) to measure each feature's
+	// `RecallImpact` on. This costs a query per validation point per used feature.
+	Validation [][]byte
+	// Seed for the permutations used to measure recall impact. Zero means pick one at random.
+	Seed int64
+}
+
+// Report how much the forest relies on each feature, most-used first (ties broken by feature
+// number). Every feature gets an entry, including unused ones, as long as we know how many
+// features there are (from the training data, feature names or validation set); otherwise just
+// the used ones do.
+func (forest RandomBinaryForest) FeatureImportance(opts ImportanceOptions) ([]FeatureImportance, error) {
+	numFeatures := len(forest.featureNames)
+	if len(forest.featureArray) > 0 && len(forest.featureArray[0]) > numFeatures {
+		numFeatures = len(forest.featureArray[0])
+	}
+	for i, point := range opts.Validation {
+		if len(point) != len(opts.Validation[0]) {
+			return nil, fmt.Errorf("rbf: validation data is ragged: row 0 has %d features but row %d has %d",
+				len(opts.Validation[0]), i, len(point))
+		}
+	}
+	if len(opts.Validation) > 0 && len(opts.Validation[0]) > numFeatures {
+		numFeatures = len(opts.Validation[0])
+	}
+
+	// Gather split counts, depths and balance:
+	var importances []FeatureImportance
+	var totalDepths, totalBalances []float64
+	grow := func(featureNum int32) {
+		for int(featureNum) >= len(importances) {
+			importances = append(importances, FeatureImportance{FeatureNum: int32(len(importances)), RecallImpact: math.NaN()})
+			totalDepths = append(totalDepths, 0)
+			totalBalances = append(totalBalances, 0)
+		}
+	}
+	grow(int32(numFeatures) - 1)
+	for _, tree := range forest.Trees {
+		visitSplit := func(arrayPos int32, depth int, leftRows, rightRows int) {
+			featureNum, _ := tree.split(arrayPos)
+			grow(featureNum)
+			importance := &importances[featureNum]
+			importance.NumSplits += 1
+			for len(importance.SplitDepths) <= depth {
+				importance.SplitDepths = append(importance.SplitDepths, 0)
+			}
+			importance.SplitDepths[depth] += 1
+			totalDepths[featureNum] += float64(depth)
+			totalBalances[featureNum] += splitBalance(leftRows, rightRows)
+		}
+		tree.walk(func(int32, int) {}, visitSplit)
+	}
+	if len(opts.Validation) > 0 && len(opts.Validation[0]) < len(importances) {
+		return nil, fmt.Errorf("rbf: validation data has %d features; expected %d", len(opts.Validation[0]), len(importances))
+	}
+	for featureNum := range importances {
+		importance := &importances[featureNum]
+		importance.FeatureName = forest.featureName(int32(featureNum))
+		if importance.NumSplits > 0 {
+			importance.MeanDepth = totalDepths[featureNum] / float64(importance.NumSplits)
+			importance.MeanBalance = totalBalances[featureNum] / float64(importance.NumSplits)
+		}
+	}
+
+	if len(opts.Validation) > 0 {
+		forest.addRecallImpacts(importances, opts)
+	}
+	sort.SliceStable(importances, func(i, j int) bool { return importances[i].NumSplits > importances[j].NumSplits })
+	return importances, nil
+}
+
+// Permutation importance: for each used feature, shuffle its values among the validation points
+// and see how many of each point's original candidates its query still finds.
+func (forest RandomBinaryForest) addRecallImpacts(importances []FeatureImportance, opts ImportanceOptions) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	originals := make([]map[int32]bool, len(opts.Validation))
+	for i, point := range opts.Validation {
+		originals[i] = forest.FindPointDedupResults(point)
+	}
+	permuted := make([]byte, len(opts.Validation[0]))
+	for featureNum := range importances {
+		if importances[featureNum].NumSplits == 0 {
+			importances[featureNum].RecallImpact = 0 // no split looks at it, so it can't matter
+			continue
+		}
+		permutation := rng.Perm(len(opts.Validation))
+		var totalRecall float64
+		numCounted := 0
+		for i, point := range opts.Validation {
+			if len(originals[i]) == 0 {
+				continue
+			}
+			copy(permuted, point)
+			permuted[featureNum] = opts.Validation[permutation[i]][featureNum]
+			found := 0
+			for index := range forest.FindPointDedupResults(permuted) {
+				if originals[i][index] {
+					found += 1
+				}
+			}
+			totalRecall += float64(found) / float64(len(originals[i]))
+			numCounted += 1
+		}
+		if numCounted > 0 {
+			importances[featureNum].RecallImpact = 1 - totalRecall/float64(numCounted)
+		} else {
+			importances[featureNum].RecallImpact = 0
+		}
+	}
+}
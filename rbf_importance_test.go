@@ -0,0 +1,50 @@
+package rbf
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFeatureImportance(t *testing.T) {
+	// given a forest trained on data where only the first feature varies:
+	points := make([][]byte, 64)
+	for i := range points {
+		points[i] = []byte{byte(4 * i), 9}
+	}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(3), WithTreeDepth(5), WithLeafSize(4),
+		WithNumFeaturesToCompare(2), WithSeed(1)))
+	forest = forest.WithFeatureNames([]string{"varies", "constant"})
+
+	// when we ask for feature importance without a validation set:
+	importances, err := forest.FeatureImportance(ImportanceOptions{})
+	// then the varying feature comes first with all the splits, and both have names:
+	if err != nil || len(importances) != 2 {
+		t.Fatalf("FeatureImportance == (%v, %v); expected 2 entries", importances, err)
+	}
+	stats := forest.Stats()
+	if importances[0].FeatureName != "varies" || importances[0].NumSplits != stats.Total.NumInternalNodes || importances[0].SplitDepths[0] != 3 {
+		t.Errorf("importances[0] == %+v; expected all %d splits (3 at the root) on \"varies\"", importances[0], stats.Total.NumInternalNodes)
+	}
+	if importances[1].FeatureName != "constant" || importances[1].NumSplits != 0 {
+		t.Errorf("importances[1] == %+v; expected no splits on \"constant\"", importances[1])
+	}
+	if importances[0].MeanBalance <= 0.25 || !math.IsNaN(importances[0].RecallImpact) {
+		t.Errorf("importances[0] == %+v; expected balanced splits and no recall impact", importances[0])
+	}
+	if !strings.HasPrefix(importances[0].String(), "varies: ") {
+		t.Errorf("String() == %q; expected it to start with the feature name", importances[0].String())
+	}
+
+	// and when we give it a validation set:
+	importances, err = forest.FeatureImportance(ImportanceOptions{Validation: points[:32], Seed: 1})
+	// then shuffling the varying feature loses candidates and shuffling the constant one doesn't:
+	if err != nil || importances[0].RecallImpact <= 0.5 || importances[1].RecallImpact != 0 {
+		t.Errorf("FeatureImportance == (%v, %v); expected a big recall impact for \"varies\" only", importances, err)
+	}
+
+	// and a validation set that's too narrow is rejected:
+	if _, err := forest.FeatureImportance(ImportanceOptions{Validation: [][]byte{{1}}}); err == nil {
+		t.Errorf("expected an error for validation data with too few features")
+	}
+}
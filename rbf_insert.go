@@ -0,0 +1,135 @@
+package rbf
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// Use these options when `Insert` splits leaves (and `Compact` merges them). Training sets them automatically, but they
+// aren't serialized, so a forest read from a reader uses the defaults (see `NewTrainOptions`)
+// unless they're set again here. Only LeafSize, NumFeaturesToCompare, Seed and SplitStrategy
+// matter.
+func (forest RandomBinaryForest) WithTrainOptions(opts TrainOptions) RandomBinaryForest {
+	forest.trainOptions = opts
+	return forest
+}
+
+// The options `Insert` and `Compact` use (see `WithTrainOptions`). Panics if they're invalid.
+// Pre-req: the forest has training data.
+func (forest RandomBinaryForest) updateOptions() TrainOptions {
+	opts := forest.trainOptions
+	if opts.LeafSize == 0 {
+		opts = NewTrainOptions()
+	}
+	check(opts.validateParams(len(forest.featureArray[0])))
+	return opts
+}
+
+// Returned (as a panic) by `Insert`, `Delete`, `Compact` and `SortedByQuality` on the forest of a
+// `Classifier` or `Regressor`, whose per-leaf data they can't keep up to date.
+var ErrFrozenForest = errors.New("rbf: can't change a Classifier's or Regressor's forest")
+
+// Add a point to the forest without retraining. Returns the new point's index (i.e. the index
+// that queries will return for it), which is the number of points already in the forest.
+//
+// In each tree we route the point down to its leaf and add it there. If that makes the leaf
+// reach LeafSize (see `WithTrainOptions`) we split it the same way training would, unless the
+// leaf is already as deep as the tree allows. So trees with the implicit layout never need to
+// grow (their arrays already have room for every node down to TreeDepth), and trees keep their
+// layout. Other leaves keep their positions, but a split leaf's points reach one of its new
+// leaves instead, so signatures from `Encode` for those points change.
+//
+// The forest's training data (see `WithTrainingData`) has to be attached, since the point is
+// appended to it and splits need it. The caller's slice isn't modified: the first insert copies
+// it. Panics if there's no training data, if the point has the wrong number of features, or with
+// ErrFrozenForest if this is a `Classifier` or `Regressor`'s forest.
+//
+// Insert isn't safe to call concurrently with queries or other inserts.
+func (forest *RandomBinaryForest) Insert(point []byte) int32 {
+	if forest.frozen {
+		panic(ErrFrozenForest)
+	}
+	if len(forest.featureArray) == 0 {
+		panic(ErrNoTrainingData)
+	}
+	if numFeatures := len(forest.featureArray[0]); len(point) != numFeatures {
+		panic(fmt.Errorf("rbf: point has %d features; the forest has %d", len(point), numFeatures))
+	}
+	opts := forest.updateOptions()
+
+	index := int32(len(forest.featureArray))
+	if !forest.ownsFeatureArray {
+		forest.featureArray = append([][]byte(nil), forest.featureArray...)
+		forest.ownsFeatureArray = true
+	}
+	forest.featureArray = append(forest.featureArray, append([]byte(nil), point...))
+	forest.numRows = index + 1
+	for treeNum := range forest.Trees {
+		tree := &forest.Trees[treeNum]
+		arrayPos, depth := tree.findLeafAndDepth(point)
+		tree.appendToLeaf(arrayPos, index)
+		if int32(len(tree.leafIndices(arrayPos))) >= opts.LeafSize && !tree.isDepthLimited(arrayPos, depth) {
+			params := &treeTrainingParams{
+				featureArray:         forest.featureArray,
+				leafSize:             opts.LeafSize,
+				numFeatures:          int32(len(point)),
+				numFeaturesToCompare: opts.NumFeaturesToCompare,
+				splitStrategy:        opts.SplitStrategy,
+				rng:                  rand.New(rand.NewSource(treeSeed(opts.Seed^int64(index), int32(treeNum)))),
+			}
+			tree.splitLeaf(params, arrayPos)
+		}
+		// Moving leaves to the end of rowIndex (see `appendToLeaf`) leaves holes behind; once
+		// they take up more room than the rows themselves, squeeze them out.
+		if len(tree.rowIndex) > 2*len(forest.featureArray) {
+			tree.packRowIndex()
+		}
+	}
+	return index
+}
+
+// Add a row to a leaf. A leaf's rows have to be contiguous in rowIndex, so unless the leaf is
+// already at the end of rowIndex we copy it there first (leaving its old slots unused until
+// `packRowIndex` or `Compact` reclaims them).
+func (tree *RandomBinaryTree) appendToLeaf(arrayPos, index int32) {
+	indexStart, indexEnd := tree.leafRange(arrayPos)
+	if int(indexEnd) != len(tree.rowIndex) {
+		newStart := int32(len(tree.rowIndex))
+		tree.rowIndex = append(tree.rowIndex, tree.rowIndex[indexStart:indexEnd]...)
+		indexStart, indexEnd = newStart, int32(len(tree.rowIndex))
+	}
+	tree.rowIndex = append(tree.rowIndex, index)
+	tree.setLeaf(arrayPos, indexStart, indexEnd+1)
+}
+
+// Split a leaf in two, as `calculateOneNode` would (but just one level). If every row would go
+// the same way (e.g. they're all identical) we leave it as a leaf instead of growing the tree
+// for nothing. Returns whether the leaf was split.
+// Pre-req: the leaf isn't depth-limited.
+func (tree *RandomBinaryTree) splitLeaf(params *treeTrainingParams, arrayPos int32) bool {
+	indexStart, indexEnd := tree.leafRange(arrayPos)
+	featureNum, splitValue, indexSplit := splitNode(params, tree.rowIndex, indexStart, indexEnd)
+	if indexSplit == indexStart || indexSplit == indexEnd {
+		return false
+	}
+	left, right := tree.addChildren(arrayPos)
+	tree.setSplit(arrayPos, featureNum, int32(splitValue))
+	tree.setLeaf(left, indexStart, indexSplit)
+	tree.setLeaf(right, indexSplit, indexEnd)
+	// TODO: remove numLeaves and numInternalNodes
+	tree.numInternalNodes += 1
+	tree.numLeaves += 1
+	return true
+}
+
+// Rewrite rowIndex so the leaves are contiguous (in depth-first order) with no unused slots.
+func (tree *RandomBinaryTree) packRowIndex() {
+	rowIndex := make([]int32, 0, tree.numRows())
+	tree.forEachLeaf(func(arrayPos int32) {
+		indexStart := int32(len(rowIndex))
+		rowIndex = append(rowIndex, tree.leafIndices(arrayPos)...)
+		tree.setLeaf(arrayPos, indexStart, int32(len(rowIndex)))
+	})
+	tree.rowIndex = rowIndex
+}
@@ -0,0 +1,157 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	// given a small forest:
+	allPoints := newRandomPoints(550, 4, 1, 3)
+	points := allPoints[:50]
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(3), WithTreeDepth(10), WithLeafSize(8), WithSeed(1)))
+	initialNumLeaves := forest.Trees[0].numLeaves
+
+	// when we insert many more points than the trees were trained on:
+	inserted := allPoints[50:]
+	for i, point := range inserted {
+		if index := forest.Insert(point); index != int32(len(points)+i) {
+			t.Fatalf("Insert returned %d; expected %d", index, len(points)+i)
+		}
+	}
+
+	// then every point (old and new) can find itself:
+	for i, point := range allPoints {
+		if !forest.FindPointDedupResults(point)[int32(i)] {
+			t.Errorf("point %d didn't find itself", i)
+		}
+	}
+	for treeNum, tree := range forest.Trees {
+		// and every tree still indexes every point exactly once:
+		counts := make([]int, len(points)+len(inserted))
+		tree.forEachLeaf(func(arrayPos int32) {
+			for _, index := range tree.leafIndices(arrayPos) {
+				counts[index] += 1
+			}
+		})
+		for index, count := range counts {
+			if count != 1 {
+				t.Errorf("tree %d: point %d is in %d leaves; expected 1", treeNum, index, count)
+			}
+		}
+		// and leaves were split to keep them small (there were at most 50 / 4 to start with, so
+		// they'd otherwise average at least 550 / 12 points):
+		if tree.numLeaves <= initialNumLeaves {
+			t.Errorf("tree %d: %d leaves after inserting; expected more than %d", treeNum, tree.numLeaves, initialNumLeaves)
+		}
+		// and unused slots in rowIndex are eventually reclaimed:
+		if len(tree.rowIndex) > 2*len(counts) {
+			t.Errorf("tree %d: len(rowIndex) == %d; expected at most %d", treeNum, len(tree.rowIndex), 2*len(counts))
+		}
+	}
+}
+
+func TestInsertDoesNotSplitIdenticalPoints(t *testing.T) {
+	// given a forest:
+	points := [][]byte{{0, 0}, {255, 255}}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(1), WithTreeDepth(2), WithLeafSize(2)))
+	treeSize := len(forest.Trees[0].treeFirst)
+	// when we insert the same point many times:
+	for i := 0; i < 100; i++ {
+		forest.Insert([]byte{7, 7})
+	}
+	// then the tree doesn't grow trying to split them apart:
+	if len(forest.Trees[0].treeFirst) > treeSize+2 {
+		t.Errorf("len(treeFirst) == %d; expected at most %d", len(forest.Trees[0].treeFirst), treeSize+2)
+	}
+	if len(forest.FindPointDedupResults([]byte{7, 7})) < 100 {
+		t.Errorf("expected to find all 100 inserted points")
+	}
+}
+
+func TestInsertPanicsWithoutTrainingData(t *testing.T) {
+	defer func() {
+		if recover() != ErrNoTrainingData {
+			t.Errorf("expected panic with ErrNoTrainingData")
+		}
+	}()
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	forest.Insert([]byte{0, 0, 0})
+}
+
+func TestInsertKeepsLayoutAndDepthLimit(t *testing.T) {
+	points := newRandomPoints(1020, 2, 1, 1)
+	for _, layout := range []NodeLayout{ImplicitLayout, CompactLayout} {
+		// given a forest with each layout:
+		forest, _ := TrainForestWithOptions(points[:20], NewTrainOptions(WithNumTrees(2), WithTreeDepth(4), WithLeafSize(4),
+			WithLayout(layout), WithSeed(1)))
+		implicitSize := len(forest.Trees[0].treeFirst)
+		// when we insert lots of points:
+		for _, point := range points[20:] {
+			forest.Insert(point)
+		}
+		for treeNum, tree := range forest.Trees {
+			// then the tree keeps its layout (and an implicit tree's arrays don't grow):
+			if (tree.treeChild == nil) != (layout == ImplicitLayout) || (layout == ImplicitLayout && len(tree.treeFirst) != implicitSize) {
+				t.Errorf("layout %d, tree %d: treeChild == nil is %v, len(treeFirst) == %d", layout, treeNum, tree.treeChild == nil, len(tree.treeFirst))
+			}
+			// and it's no deeper than it was allowed to be:
+			if stats := tree.Stats(); stats.MaxDepth != 3 || stats.NumDepthLimitedLeaves == 0 {
+				t.Errorf("layout %d, tree %d: max depth %d, %d depth-limited leaves; expected 3 and some",
+					layout, treeNum, stats.MaxDepth, stats.NumDepthLimitedLeaves)
+			}
+		}
+		if !forest.FindPointDedupResults(points[0])[0] {
+			t.Errorf("layout %d: point 0 didn't find itself", layout)
+		}
+	}
+}
+
+func TestInsertKeepsSignaturesOfLeavesItDoesNotSplit(t *testing.T) {
+	// given a forest (with leaves at depth 3, where the layouts would number them differently)
+	// and a stored signature:
+	var points [][]byte
+	for i := 0; i < 16; i++ {
+		points = append(points, []byte{byte(10 * i), byte(10 * i)})
+	}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(1), WithTreeDepth(6), WithLeafSize(3)))
+	signature := forest.Encode(points[0])
+	// when we insert a point far from it (so its leaf isn't split):
+	forest.Insert([]byte{145, 145})
+	// then its signature is the same:
+	if after := forest.Encode(points[0]); !reflect.DeepEqual(after, signature) {
+		t.Errorf("Encode == %v after inserting; expected %v", after, signature)
+	}
+}
+
+func TestInsertDoesNotModifyCallersTrainingData(t *testing.T) {
+	// given training data with spare capacity:
+	points := make([][]byte, 2, 10)
+	points[0], points[1] = []byte{0, 0}, []byte{255, 255}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(1), WithTreeDepth(2), WithLeafSize(2)))
+	// when we insert a point:
+	forest.Insert([]byte{7, 7})
+	// then it isn't written into the caller's spare capacity:
+	if points[:3][2] != nil {
+		t.Errorf("points[:3][2] == %v; expected nil", points[:3][2])
+	}
+}
+
+func TestChangingClassifierOrRegressorForestPanics(t *testing.T) {
+	points := [][]byte{{0, 0}, {255, 255}}
+	classifier, _ := TrainClassifier(points, []int32{0, 1}, NewTrainOptions(WithNumTrees(1), WithTreeDepth(2), WithLeafSize(1)))
+	regressor, _ := TrainRegressor(points, []float64{0, 1}, NewTrainOptions(WithNumTrees(1), WithTreeDepth(2), WithLeafSize(1)))
+	runOneTest := func(description string, change func()) {
+		defer func() {
+			if recover() != ErrFrozenForest {
+				t.Errorf("%s: expected panic with ErrFrozenForest", description)
+			}
+		}()
+		change()
+	}
+	runOneTest("classifier Insert", func() { classifier.Forest.Insert([]byte{7, 7}) })
+	runOneTest("classifier Delete", func() { classifier.Forest.Delete(0) })
+	runOneTest("regressor Compact", func() { regressor.Forest.Compact() })
+	runOneTest("classifier SortedByQuality", func() { classifier.Forest.SortedByQuality() })
+	runOneTest("regressor SortedByQuality", func() { regressor.Forest.SortedByQuality() })
+}
@@ -49,33 +49,59 @@ func unsafelyWriteIntSlice(writer io.Writer, intSlice []int32) {
 
 //######################################################################################################################
 
+// A tree is written as:
+// - len(rowIndex), then len(treeFirst) -- negated for the compact layout, so that files written
+//   before there was a compact layout still read as implicit-layout trees
+// - rowIndex, treeFirst, treeSecond, and (compact layout only) treeChild
+// - numInternalNodes, numLeaves, and (compact layout only) maxDepth
 func readTreeFromReader(reader io.Reader) RandomBinaryTree {
 	// read lengths first so we can build slices to read
 	var lenRowIndex, lenTreeFirst int32
 	binary.Read(reader, binary.LittleEndian, &lenRowIndex)
 	binary.Read(reader, binary.LittleEndian, &lenTreeFirst)
+	compact := lenTreeFirst < 0
+	if compact {
+		lenTreeFirst = -lenTreeFirst
+	}
 
 	// now read slices
-	rowIndex := unsafelyReadIntSlice(reader, lenRowIndex)
-	treeFirst := unsafelyReadIntSlice(reader, lenTreeFirst)
-	treeSecond := unsafelyReadIntSlice(reader, lenTreeFirst)
+	tree := RandomBinaryTree{}
+	tree.rowIndex = unsafelyReadIntSlice(reader, lenRowIndex)
+	tree.treeFirst = unsafelyReadIntSlice(reader, lenTreeFirst)
+	tree.treeSecond = unsafelyReadIntSlice(reader, lenTreeFirst)
+	if compact {
+		tree.treeChild = unsafelyReadIntSlice(reader, lenTreeFirst)
+	}
 
 	// TODO: remove this
 	// and finally read node counts
-	var numInternalNodes, numLeaves int32
-	binary.Read(reader, binary.LittleEndian, &numInternalNodes)
-	binary.Read(reader, binary.LittleEndian, &numLeaves)
-	return RandomBinaryTree{rowIndex, treeFirst, treeSecond, numInternalNodes, numLeaves}
+	binary.Read(reader, binary.LittleEndian, &tree.numInternalNodes)
+	binary.Read(reader, binary.LittleEndian, &tree.numLeaves)
+	if compact {
+		binary.Read(reader, binary.LittleEndian, &tree.maxDepth)
+	}
+	return tree
 }
 
 func (tree RandomBinaryTree) writeToWriter(writer io.Writer) {
+	compact := tree.treeChild != nil
 	binary.Write(writer, binary.LittleEndian, int32(len(tree.rowIndex)))
-	binary.Write(writer, binary.LittleEndian, int32(len(tree.treeFirst)))
+	if compact {
+		binary.Write(writer, binary.LittleEndian, -int32(len(tree.treeFirst)))
+	} else {
+		binary.Write(writer, binary.LittleEndian, int32(len(tree.treeFirst)))
+	}
 	unsafelyWriteIntSlice(writer, tree.rowIndex)
 	unsafelyWriteIntSlice(writer, tree.treeFirst)
 	unsafelyWriteIntSlice(writer, tree.treeSecond)
+	if compact {
+		unsafelyWriteIntSlice(writer, tree.treeChild)
+	}
 	binary.Write(writer, binary.LittleEndian, int32(tree.numInternalNodes))
 	binary.Write(writer, binary.LittleEndian, int32(tree.numLeaves))
+	if compact {
+		binary.Write(writer, binary.LittleEndian, tree.maxDepth)
+	}
 }
 
 func ReadForestFromReader(reader io.Reader) RandomBinaryForest {
@@ -90,7 +116,7 @@ func ReadForestFromReader(reader io.Reader) RandomBinaryForest {
 		trees[i] = readTreeFromReader(reader)
 	}
 
-	return RandomBinaryForest{trees}
+	return RandomBinaryForest{Trees: trees}
 }
 
 func (forest RandomBinaryForest) WriteToWriter(writer io.Writer) {
@@ -8,7 +8,7 @@ import (
 
 func TestReadAndWriteForest(t *testing.T) {
 	// given a test RBF
-	rbf := RandomBinaryForest{[]RandomBinaryTree{NewTestTree(), NewTestTree()}}
+	rbf := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree(), NewTestTree()}}
 	var builder strings.Builder
 	// when we write it and read it back
 	rbf.WriteToWriter(&builder)
@@ -19,3 +19,18 @@ func TestReadAndWriteForest(t *testing.T) {
 		t.Errorf("deserialized forest not the same as original forest")
 	}
 }
+
+func TestReadAndWriteCompactForest(t *testing.T) {
+	// given a forest with compact-layout trees:
+	points := [][]byte{{0, 7}, {1, 6}, {2, 5}, {3, 4}, {4, 3}, {5, 2}, {6, 1}, {7, 0}}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(2), WithTreeDepth(40), WithLeafSize(2),
+		WithLayout(CompactLayout)))
+	// when we write it and read it back:
+	var builder strings.Builder
+	forest.WriteToWriter(&builder)
+	forestIn := ReadForestFromReader(strings.NewReader(builder.String()))
+	// then the trees are identical:
+	if !reflect.DeepEqual(forestIn.Trees, forest.Trees) {
+		t.Errorf("deserialized compact forest not the same as original forest")
+	}
+}
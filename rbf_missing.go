@@ -0,0 +1,61 @@
+package rbf
+
+// Queries with missing features ("wildcards").
+//
+// Some records have unknown fields, but `findPoint` needs a value for every feature and any fake
+// sentinel value would skew the path. Instead the caller passes a mask of missing features, and
+// whenever we reach a split on a missing feature we go down both sides and union the leaves.
+// That can blow up with many missing features, so the caller also gives a cap on the number of
+// leaves per tree.
+
+// Query the forest for a single point, some of whose features are missing (missing[i] is true
+// iff feature i is missing; the query point's values for those features are ignored). The mask
+// may be shorter than the query point: features past its end count as present, so e.g. a nil
+// mask is an ordinary search. At most
+// maxLeavesPerTree leaves are visited in each tree (zero or negative means no limit).
+// Returns: same as `FindPointAllResults`, i.e. for each tree in the forest, a slice of indices into
+// the training feature-array.
+func (forest RandomBinaryForest) FindPointWithMissing(queryPoint []byte, missing []bool, maxLeavesPerTree int) (count int, results [][]int32) {
+	results = make([][]int32, len(forest.Trees))
+	for i, tree := range forest.Trees {
+		results[i] = tree.findPointWithMissing(queryPoint, missing, maxLeavesPerTree)
+		count += len(results[i])
+	}
+	return
+}
+
+// Same as `FindPointWithMissing` but dedups results across trees.
+func (forest RandomBinaryForest) FindPointWithMissingDedupResults(queryPoint []byte, missing []bool, maxLeavesPerTree int) map[int32]bool {
+	resultIndices := make(map[int32]bool)
+	for _, tree := range forest.Trees {
+		for _, index := range tree.findPointWithMissing(queryPoint, missing, maxLeavesPerTree) {
+			resultIndices[index] = true
+		}
+	}
+	return resultIndices
+}
+
+// Depth-first, left before right, so which leaves we get under the cap is deterministic.
+func (tree RandomBinaryTree) findPointWithMissing(queryPoint []byte, missing []bool, maxLeaves int) []int32 {
+	var results []int32
+	stack := []int32{0}
+	for leavesVisited := 0; len(stack) > 0 && (maxLeaves <= 0 || leavesVisited < maxLeaves); {
+		arrayPos := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for !tree.isLeaf(arrayPos) {
+			featureNum, splitValue := tree.split(arrayPos)
+			left, right := tree.children(arrayPos)
+			if int(featureNum) < len(missing) && missing[featureNum] {
+				stack = append(stack, right) // come back for this later
+				arrayPos = left
+			} else if int32(queryPoint[featureNum]) <= splitValue {
+				arrayPos = left
+			} else {
+				arrayPos = right
+			}
+		}
+		results = append(results, tree.leafIndices(arrayPos)...)
+		leavesVisited += 1
+	}
+	return results
+}
@@ -0,0 +1,30 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPointWithMissing(t *testing.T) {
+	// given the test tree (split on feature 0; "abc" on the left, "aaa" on the right):
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	queryPoint := []byte{6, 0, 0, 0, 0, 0}
+
+	runOneTest := func(missing []bool, maxLeaves int, expected []int32) {
+		count, results := forest.FindPointWithMissing(queryPoint, missing, maxLeaves)
+		if count != len(expected) || !reflect.DeepEqual(results[0], expected) {
+			t.Errorf("missing == %v, maxLeaves == %d: (count, results) == (%d, %v); expected %v",
+				missing, maxLeaves, count, results[0], expected)
+		}
+	}
+
+	// when some other feature is missing, then it's an ordinary search:
+	runOneTest([]bool{false, true, false, false, false, false}, 0, []int32{0})
+	// when the split feature is missing, then we get both leaves (left first):
+	runOneTest([]bool{true, false, false, false, false, false}, 0, []int32{1, 0})
+	// unless we cap the number of leaves:
+	runOneTest([]bool{true, false, false, false, false, false}, 1, []int32{1})
+	// and features past the end of a short mask are present:
+	runOneTest([]bool{true}, 0, []int32{1, 0})
+	runOneTest(nil, 0, []int32{0})
+}
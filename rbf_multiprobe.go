@@ -0,0 +1,99 @@
+package rbf
+
+import (
+	"container/heap"
+)
+
+// Multi-probe search: trade query latency for recall without training more trees.
+//
+// A plain search follows a single root-to-leaf path, so a query value that sits right at a split
+// value loses its true neighbors on the other side of the split. Here, as we walk down the tree,
+// we remember each sibling subtree we skipped along with how far the query was from that split.
+// Once we've got the usual leaf we visit the closest of those skipped subtrees (descending them
+// the same way, and remembering *their* skipped subtrees too) until we've seen the requested
+// number of extra leaves.
+//
+// The cost of a skipped subtree is the largest split-margin along the path to it, i.e. how far
+// the query would have had to move in a single feature to land there. This is the same idea as
+// Spotify annoy's priority-queue search.
+
+// Query the forest for a single point, visiting up to `extraLeavesPerTree` additional leaves in
+// each tree (in order of increasing cost, see above).
+// Returns: same as `FindPointAllResults`, i.e. for each tree in the forest, a slice of indices into
+// the training feature-array. Results within a tree are distinct (leaves don't overlap), but
+// different trees can return the same indices.
+func (forest RandomBinaryForest) FindPointMultiProbe(queryPoint []byte, extraLeavesPerTree int) (count int, results [][]int32) {
+	results = make([][]int32, len(forest.Trees))
+	for i, tree := range forest.Trees {
+		results[i] = tree.multiProbe(queryPoint, extraLeavesPerTree)
+		count += len(results[i])
+	}
+	return
+}
+
+// Same as `FindPointMultiProbe` but dedups results across trees.
+func (forest RandomBinaryForest) FindPointMultiProbeDedupResults(queryPoint []byte, extraLeavesPerTree int) map[int32]bool {
+	resultIndices := make(map[int32]bool)
+	for _, tree := range forest.Trees {
+		for _, index := range tree.multiProbe(queryPoint, extraLeavesPerTree) {
+			resultIndices[index] = true
+		}
+	}
+	return resultIndices
+}
+
+func (tree RandomBinaryTree) multiProbe(queryPoint []byte, extraLeaves int) []int32 {
+	var results []int32
+	queue := &probeQueue{{0, 0}} // start at the root at no cost
+	for leavesVisited := 0; leavesVisited <= extraLeaves && queue.Len() > 0; leavesVisited++ {
+		probe := heap.Pop(queue).(probe)
+		arrayPos := probe.arrayPos
+		for !tree.isLeaf(arrayPos) {
+			featureNum, splitValue := tree.split(arrayPos)
+			left, right := tree.children(arrayPos)
+			queryValue := int32(queryPoint[featureNum])
+			// margin: how much the query value would have to change to go the other way
+			if queryValue <= splitValue {
+				heap.Push(queue, probe.skip(right, splitValue+1-queryValue))
+				arrayPos = left
+			} else {
+				heap.Push(queue, probe.skip(left, queryValue-splitValue))
+				arrayPos = right
+			}
+		}
+		results = append(results, tree.leafIndices(arrayPos)...)
+	}
+	return results
+}
+
+// A subtree we skipped, and the cost of visiting it.
+type probe struct {
+	cost     int32
+	arrayPos int32
+}
+
+func (p probe) skip(arrayPos int32, margin int32) probe {
+	if margin < p.cost {
+		margin = p.cost
+	}
+	return probe{margin, arrayPos}
+}
+
+// Min-heap of probes, ordered by cost and then by position so the search is deterministic.
+type probeQueue []probe
+
+func (q probeQueue) Len() int { return len(q) }
+func (q probeQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].arrayPos < q[j].arrayPos
+}
+func (q probeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *probeQueue) Push(x interface{}) { *q = append(*q, x.(probe)) }
+func (q *probeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
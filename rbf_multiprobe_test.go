@@ -0,0 +1,32 @@
+package rbf
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindPointMultiProbe(t *testing.T) {
+	// given a 1-feature tree over 0..7 with 4 leaves {0,1}, {2,3}, {4,5}, {6,7}:
+	points := [][]byte{{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7}}
+	forest := TrainForest(points, 1, 3, 2, 1)
+	queryPoint := []byte{3}
+
+	runOneTest := func(extraLeaves int, expected []int32) {
+		count, results := forest.FindPointMultiProbe(queryPoint, extraLeaves)
+		actual := append([]int32{}, results[0]...)
+		sort.Slice(actual, func(i, j int) bool { return actual[i] < actual[j] })
+		if count != len(expected) || !reflect.DeepEqual(actual, expected) {
+			t.Errorf("extraLeaves == %d: (count, results) == (%d, %v); expected %v", extraLeaves, count, actual, expected)
+		}
+	}
+
+	// with no extra leaves it's an ordinary search:
+	runOneTest(0, []int32{2, 3})
+	// 3 is right at the root's split so the first extra leaf is across it:
+	runOneTest(1, []int32{2, 3, 4, 5})
+	// then the sibling leaf {0, 1} (margin 2) before {6, 7} (margin 3):
+	runOneTest(2, []int32{0, 1, 2, 3, 4, 5})
+	// and asking for more leaves than there are is fine:
+	runOneTest(10, []int32{0, 1, 2, 3, 4, 5, 6, 7})
+}
@@ -0,0 +1,62 @@
+package rbf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Returned by queries that need the training feature-array when the forest doesn't have one
+// (e.g. it was read from a reader and `WithTrainingData` wasn't called).
+var ErrNoTrainingData = errors.New("rbf: forest has no training data attached")
+
+// A single result of a nearest-neighbor query: an index into the training feature-array and
+// that row's distance to the query point.
+type Neighbor struct {
+	Index    int32
+	Distance float64
+}
+
+// Return a copy of the forest that uses the given training feature-array for re-ranking.
+// The feature-array must be the one the forest was trained on (or at least have the same rows in
+// the same order), otherwise results will be meaningless.
+func (forest RandomBinaryForest) WithTrainingData(featureArray [][]byte) RandomBinaryForest {
+	forest.featureArray = featureArray
+	forest.ownsFeatureArray = false
+	return forest
+}
+
+// Query the forest for the k nearest training points to a single point.
+// Candidates from all trees are deduped, re-ranked by their distance to the query point (using
+// the forest's metric; see `WithDistance`), and the k best are returned in order of increasing
+// distance. Ties are broken by index so results are deterministic. Fewer than k results are
+// returned if the trees don't return enough candidates. k has to be at least 1.
+func (forest RandomBinaryForest) FindNearest(queryPoint []byte, k int) ([]Neighbor, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("rbf: k must be at least 1 (got %d)", k)
+	}
+	if forest.featureArray == nil {
+		return nil, ErrNoTrainingData
+	}
+	candidates := forest.FindPointDedupResults(queryPoint)
+	distance := forest.metric()
+	neighbors := make([]Neighbor, 0, len(candidates))
+	for index := range candidates {
+		neighbors = append(neighbors, Neighbor{index, distance.Distance(queryPoint, forest.featureArray[index])})
+	}
+	sortNeighbors(neighbors)
+	if k < len(neighbors) {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// Sort by distance, breaking ties by index.
+func sortNeighbors(neighbors []Neighbor) {
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Distance != neighbors[j].Distance {
+			return neighbors[i].Distance < neighbors[j].Distance
+		}
+		return neighbors[i].Index < neighbors[j].Index
+	})
+}
@@ -0,0 +1,45 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindNearest(t *testing.T) {
+	// given a single-leaf forest (depth 1) so every point is a candidate:
+	points := [][]byte{{0, 0}, {10, 10}, {1, 1}, {2, 0}, {0, 2}}
+	forest := TrainForest(points, 1, 1, 1, 1)
+	// when:
+	neighbors, err := forest.FindNearest([]byte{1, 1}, 3)
+	// then we get the exact match first and ties (distance 2) broken by index:
+	if err != nil {
+		t.Fatalf("FindNearest returned error %v", err)
+	}
+	expected := []Neighbor{{2, 0}, {0, 2}, {3, 2}}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("neighbors == %v; expected %v", neighbors, expected)
+	}
+	// and k has to be positive:
+	for _, k := range []int{0, -1} {
+		if _, err := forest.FindNearest([]byte{1, 1}, k); err == nil {
+			t.Errorf("k == %d: expected an error", k)
+		}
+	}
+}
+
+func TestFindNearestNeedsTrainingData(t *testing.T) {
+	// given a forest with no training data:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	queryPoint := []byte{6, 0, 0, 0, 0, 0}
+	// when/then:
+	if _, err := forest.FindNearest(queryPoint, 1); err != ErrNoTrainingData {
+		t.Errorf("err == %v; expected %v", err, ErrNoTrainingData)
+	}
+	// and once we attach it we get the one point in the leaf:
+	forest = forest.WithTrainingData([][]byte{{6, 0, 0, 0, 0, 1}, {1, 2, 0, 0, 0, 0}})
+	neighbors, _ := forest.FindNearest(queryPoint, 5)
+	expected := []Neighbor{{0, 1}}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("neighbors == %v; expected %v", neighbors, expected)
+	}
+}
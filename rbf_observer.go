@@ -0,0 +1,112 @@
+package rbf
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// What training decided to do with a node.
+type NodeKind int32
+
+const (
+	// Split on a feature.
+	InternalNode NodeKind = iota
+	// A leaf because it had fewer than LeafSize rows (or they all had the same label).
+	SizeLimitedLeaf
+	// A leaf because the tree couldn't get any deeper.
+	DepthLimitedLeaf
+	// A leaf because no feature we tried could split its rows (compact layout only; the implicit
+	// layout makes a degenerate split instead).
+	UnsplittableLeaf
+)
+
+func (kind NodeKind) String() string {
+	switch kind {
+	case InternalNode:
+		return "internal"
+	case SizeLimitedLeaf:
+		return "size-limited-leaf"
+	case DepthLimitedLeaf:
+		return "depth-limited-leaf"
+	case UnsplittableLeaf:
+		return "unsplittable-leaf"
+	}
+	return "NodeKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// One node, as it's trained.
+type NodeEvent struct {
+	TreeNum  int32
+	ArrayPos int32 // position in the tree arrays
+	Depth    int32 // the root is at depth 0
+	Kind     NodeKind
+	// The node's view into the tree's rowIndex (so it has IndexEnd-IndexStart rows).
+	IndexStart int32
+	IndexEnd   int32
+	// For internal nodes only: rows in [IndexStart, IndexSplit) went left and the rest went right
+	// (if IndexSplit is IndexStart or IndexEnd the split was degenerate), because their value of
+	// feature FeatureNum was <= SplitValue (or not).
+	IndexSplit int32
+	FeatureNum int32
+	SplitValue byte
+}
+
+// Gets told about each node as it's trained, e.g. to collect statistics or log the trees' shape.
+// Trees are trained in parallel, so ObserveNode is called from several goroutines at once and
+// has to be safe for that. Within one tree, nodes arrive in depth-first (left-to-right) order.
+type TrainObserver interface {
+	ObserveNode(event NodeEvent)
+}
+
+func (params *treeTrainingParams) observe(event NodeEvent) {
+	if params.observer != nil {
+		params.observer.ObserveNode(event)
+	}
+}
+
+// A TrainObserver that writes one CSV line per node (after a header line), with the columns:
+// tree, array_pos, depth, kind, index_start, index_end, num_rows, index_split, feature_num,
+// split_value, feature_name. The split columns are empty for leaves, and feature_name is empty
+// unless FeatureNames is set. Call Flush once training is done.
+type CSVObserver struct {
+	FeatureNames []string
+
+	mutex         sync.Mutex
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func NewCSVObserver(writer io.Writer) *CSVObserver {
+	return &CSVObserver{writer: csv.NewWriter(writer)}
+}
+
+func (observer *CSVObserver) ObserveNode(event NodeEvent) {
+	itoa := func(i int32) string { return strconv.Itoa(int(i)) }
+	record := []string{itoa(event.TreeNum), itoa(event.ArrayPos), itoa(event.Depth), event.Kind.String(),
+		itoa(event.IndexStart), itoa(event.IndexEnd), itoa(event.IndexEnd - event.IndexStart), "", "", "", ""}
+	if event.Kind == InternalNode {
+		record[7], record[8], record[9] = itoa(event.IndexSplit), itoa(event.FeatureNum), itoa(int32(event.SplitValue))
+		if int(event.FeatureNum) < len(observer.FeatureNames) {
+			record[10] = observer.FeatureNames[event.FeatureNum]
+		}
+	}
+
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	if !observer.headerWritten {
+		observer.writer.Write([]string{"tree", "array_pos", "depth", "kind", "index_start", "index_end", "num_rows",
+			"index_split", "feature_num", "split_value", "feature_name"})
+		observer.headerWritten = true
+	}
+	observer.writer.Write(record)
+}
+
+// Flush buffered lines to the underlying writer. Returns the first error from writing, if any.
+func (observer *CSVObserver) Flush() error {
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	observer.writer.Flush()
+	return observer.writer.Error()
+}
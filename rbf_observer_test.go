@@ -0,0 +1,82 @@
+package rbf
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type countingObserver struct {
+	mutex  sync.Mutex
+	counts map[NodeKind]int
+	rows   map[int32]int32 // tree -> number of rows in its leaves
+}
+
+func (observer *countingObserver) ObserveNode(event NodeEvent) {
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	observer.counts[event.Kind] += 1
+	if event.Kind != InternalNode {
+		observer.rows[event.TreeNum] += event.IndexEnd - event.IndexStart
+	}
+}
+
+func TestTrainObserver(t *testing.T) {
+	// given an observer:
+	observer := &countingObserver{counts: map[NodeKind]int{}, rows: map[int32]int32{}}
+	points := [][]byte{{0, 7}, {1, 6}, {2, 5}, {3, 4}, {4, 3}, {5, 2}, {6, 1}, {7, 0}}
+	// when we train with it:
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(3), WithTreeDepth(3), WithLeafSize(2),
+		WithObserver(observer)))
+	// then it sees every node in every tree, and every row ends up in a leaf:
+	var numInternalNodes, numLeaves int
+	for _, tree := range forest.Trees {
+		numInternalNodes += int(tree.numInternalNodes)
+		numLeaves += int(tree.numLeaves)
+	}
+	if observer.counts[InternalNode] != numInternalNodes || observer.counts[SizeLimitedLeaf]+observer.counts[DepthLimitedLeaf] != numLeaves {
+		t.Errorf("observed %v; expected %d internal nodes and %d leaves", observer.counts, numInternalNodes, numLeaves)
+	}
+	for treeNum := int32(0); treeNum < 3; treeNum++ {
+		if observer.rows[treeNum] != int32(len(points)) {
+			t.Errorf("tree %d: observed %d rows in leaves; expected %d", treeNum, observer.rows[treeNum], len(points))
+		}
+	}
+}
+
+func TestCSVObserver(t *testing.T) {
+	// given a CSV observer:
+	var builder strings.Builder
+	observer := NewCSVObserver(&builder)
+	observer.FeatureNames = []string{"x", "y"}
+	points := [][]byte{{0, 7}, {1, 6}, {2, 5}, {3, 4}}
+	// when we train twice with it (which used to write to a closed file the second time):
+	for i := 0; i < 2; i++ {
+		TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(2), WithTreeDepth(2), WithLeafSize(2), WithObserver(observer)))
+	}
+	if err := observer.Flush(); err != nil {
+		t.Fatalf("Flush returned error %v", err)
+	}
+	// then we get a header and well-formed lines for both runs:
+	records, err := csv.NewReader(strings.NewReader(builder.String())).ReadAll()
+	if err != nil || len(records) < 5 || records[0][0] != "tree" {
+		t.Fatalf("records == %v (err %v); expected a header and some nodes", records, err)
+	}
+	for _, record := range records[1:] {
+		if record[3] == "internal" && record[10] != "x" && record[10] != "y" {
+			t.Errorf("internal node %v has no feature name", record)
+		}
+	}
+}
+
+func TestTrainingHasNoFilesystemSideEffects(t *testing.T) {
+	// when we train (without an observer), then nothing gets written to the working directory:
+	TrainForestWithOptions([][]byte{{0}, {1}}, NewTrainOptions(WithTreeDepth(2), WithLeafSize(1)))
+	for _, filename := range []string{"train.log", "tree_stats.txt"} {
+		if _, err := os.Stat(filename); err == nil {
+			t.Errorf("found %s in the working directory", filename)
+		}
+	}
+}
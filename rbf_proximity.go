@@ -0,0 +1,116 @@
+package rbf
+
+import (
+	"sort"
+)
+
+// Random-forest proximity: the fraction of trees in which two points land in the same leaf.
+// This is a learned similarity that, like the trees themselves, doesn't care about feature scale
+// or distribution (see "Median-splitting pros and cons" in the README).
+func (forest RandomBinaryForest) Proximity(point1, point2 []byte) float64 {
+	if len(forest.Trees) == 0 {
+		return 0
+	}
+	sameLeafCount := 0
+	for _, tree := range forest.Trees {
+		if tree.findLeaf(point1) == tree.findLeaf(point2) {
+			sameLeafCount += 1
+		}
+	}
+	return float64(sameLeafCount) / float64(len(forest.Trees))
+}
+
+// One non-zero entry in a row of a sparse proximity matrix.
+type ProximityEntry struct {
+	Index     int32
+	Proximity float64
+}
+
+// Build the (sparse) proximity matrix over the training set: row i lists every other training row
+// that shares a leaf with row i in at least one tree, along with their proximity (as in
+// `Proximity`). Entries below minProximity are dropped to keep things sparse. Each row is sorted
+// by decreasing proximity, with ties broken by index.
+//
+// There's a row for every training row, including inserted and deleted ones (deleted rows are
+// empty). This only needs the trees, not the training data, but a forest read from a reader
+// doesn't know how many rows it was trained with, so without its training data attached (see
+// `WithTrainingData`) the matrix stops at the last row still in the trees. Memory and time are
+// roughly (number of trees) x (number of training rows) x (leaf size).
+func (forest RandomBinaryForest) ProximityMatrix(minProximity float64) [][]ProximityEntry {
+	if len(forest.Trees) == 0 {
+		return nil
+	}
+	numRows := forest.trainingRowCount()
+	numTrees := float64(len(forest.Trees))
+
+	// For each tree, which leaf each training row is in (-1 if it's been deleted).
+	rowLeaves := make([][]int32, len(forest.Trees))
+	for treeNum, tree := range forest.Trees {
+		rowLeaves[treeNum] = make([]int32, numRows)
+		for row := range rowLeaves[treeNum] {
+			rowLeaves[treeNum][row] = -1
+		}
+		tree.forEachLeaf(func(arrayPos int32) {
+			for _, index := range tree.leafIndices(arrayPos) {
+				rowLeaves[treeNum][index] = arrayPos
+			}
+		})
+	}
+
+	// Now for each row count up its leaf-mates across trees.
+	matrix := make([][]ProximityEntry, numRows)
+	counts := make([]int32, numRows)
+	var touched []int32
+	for row := int32(0); row < int32(numRows); row++ {
+		touched = touched[:0]
+		for treeNum, tree := range forest.Trees {
+			if rowLeaves[treeNum][row] < 0 {
+				continue
+			}
+			for _, index := range tree.leafIndices(rowLeaves[treeNum][row]) {
+				if index == row {
+					continue
+				}
+				if counts[index] == 0 {
+					touched = append(touched, index)
+				}
+				counts[index] += 1
+			}
+		}
+
+		var entries []ProximityEntry
+		for _, index := range touched {
+			if proximity := float64(counts[index]) / numTrees; proximity >= minProximity {
+				entries = append(entries, ProximityEntry{index, proximity})
+			}
+			counts[index] = 0
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Proximity != entries[j].Proximity {
+				return entries[i].Proximity > entries[j].Proximity
+			}
+			return entries[i].Index < entries[j].Index
+		})
+		matrix[row] = entries
+	}
+	return matrix
+}
+
+// The number of training rows, including inserted and deleted ones, if we know it. Otherwise
+// (see `RandomBinaryForest.numRows`) the best we can do is one more than the largest row index
+// still in the trees.
+func (forest RandomBinaryForest) trainingRowCount() int {
+	if forest.numRows > 0 {
+		return int(forest.numRows)
+	}
+	if len(forest.featureArray) > 0 {
+		return len(forest.featureArray)
+	}
+	numRows := 0
+	for _, tree := range forest.Trees {
+		if bound := tree.rowIndexBound(); bound > numRows {
+			numRows = bound
+		}
+	}
+	return numRows
+}
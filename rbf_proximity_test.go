@@ -0,0 +1,47 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProximity(t *testing.T) {
+	// given a tree that separates "aaa" and "abc" and a tree that doesn't:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree(), newSingleLeafTree([]int32{0, 1})}}
+	point1 := []byte{6, 0, 0, 0, 0, 0}
+	point2 := []byte{0, 1, 0, 0, 0, 0}
+	// when/then they only share a leaf in one of the two trees:
+	if proximity := forest.Proximity(point1, point2); proximity != 0.5 {
+		t.Errorf("Proximity(point1, point2) == %f; expected 0.5", proximity)
+	}
+	// and a point is always in the same leaf as itself:
+	if proximity := forest.Proximity(point1, point1); proximity != 1 {
+		t.Errorf("Proximity(point1, point1) == %f; expected 1", proximity)
+	}
+}
+
+func TestProximityMatrix(t *testing.T) {
+	// given the same forest:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree(), newSingleLeafTree([]int32{0, 1})}}
+	// when/then each training row is 0.5-proximate to the other:
+	expected := [][]ProximityEntry{{{1, 0.5}}, {{0, 0.5}}}
+	if matrix := forest.ProximityMatrix(0); !reflect.DeepEqual(matrix, expected) {
+		t.Errorf("ProximityMatrix(0) == %v; expected %v", matrix, expected)
+	}
+	// and with a higher threshold there's nothing:
+	expected = [][]ProximityEntry{nil, nil}
+	if matrix := forest.ProximityMatrix(0.6); !reflect.DeepEqual(matrix, expected) {
+		t.Errorf("ProximityMatrix(0.6) == %v; expected %v", matrix, expected)
+	}
+}
+
+func TestProximityMatrixAfterDelete(t *testing.T) {
+	// given a single-leaf forest with its last row deleted:
+	forest, _ := TrainForestWithOptions([][]byte{{0}, {1}, {2}}, NewTrainOptions(WithNumTrees(2), WithTreeDepth(1)))
+	forest.Delete(2)
+	// when/then the matrix still has a (now empty) row for it:
+	expected := [][]ProximityEntry{{{1, 1}}, {{0, 1}}, nil}
+	if matrix := forest.ProximityMatrix(0); !reflect.DeepEqual(matrix, expected) {
+		t.Errorf("ProximityMatrix(0) == %v; expected %v", matrix, expected)
+	}
+}
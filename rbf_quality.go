@@ -0,0 +1,58 @@
+package rbf
+
+import (
+	"sort"
+)
+
+// Tree quality, so you can train once with many trees and choose at query time how many to
+// consult (see `QueryOptions.NumTrees`): sort the trees by quality once, save the forest, and
+// then the "first N" trees are the best N.
+//
+// We score a tree by how evenly it spreads the training rows over its leaves: the "effective
+// number of leaves" (n^2 / sum of squared leaf sizes, a.k.a. the inverse Simpson index). A tree
+// with k equal-sized leaves scores k; lopsided trees, e.g. from degenerate splits on skewed
+// features, score less since most rows end up in a few big leaves and so queries return big,
+// unselective candidate sets.
+
+// The quality score described above. Higher is better.
+func (tree RandomBinaryTree) Quality() float64 {
+	var sumOfSquares float64
+	tree.forEachLeaf(func(arrayPos int32) {
+		leafSize := float64(len(tree.leafIndices(arrayPos)))
+		sumOfSquares += leafSize * leafSize
+	})
+	if sumOfSquares == 0 {
+		return 0
+	}
+	numRows := float64(tree.numRows())
+	return numRows * numRows / sumOfSquares
+}
+
+// Tree numbers from best to worst quality. Ties are broken by tree number, so this is deterministic.
+func (forest RandomBinaryForest) TreesByQuality() []int {
+	qualities := make([]float64, len(forest.Trees))
+	order := make([]int, len(forest.Trees))
+	for i, tree := range forest.Trees {
+		qualities[i] = tree.Quality()
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return qualities[order[i]] > qualities[order[j]]
+	})
+	return order
+}
+
+// Return a copy of the forest with the trees reordered from best to worst quality. Panics with
+// ErrFrozenForest on a `Classifier` or `Regressor`'s forest, whose per-leaf data is indexed by
+// tree number (use `QueryOptions.TreeIDs` with `TreesByQuality` instead).
+func (forest RandomBinaryForest) SortedByQuality() RandomBinaryForest {
+	if forest.frozen {
+		panic(ErrFrozenForest)
+	}
+	trees := make([]RandomBinaryTree, len(forest.Trees))
+	for i, treeNum := range forest.TreesByQuality() {
+		trees[i] = forest.Trees[treeNum]
+	}
+	forest.Trees = trees
+	return forest
+}
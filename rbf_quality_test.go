@@ -0,0 +1,30 @@
+package rbf
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTreeQuality(t *testing.T) {
+	// given a single-leaf tree and the test tree (two leaves of one row each):
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{newSingleLeafTree([]int32{0, 1}), NewTestTree()}}
+	// when/then the test tree has 2 "effective leaves" and so is better:
+	if quality := forest.Trees[1].Quality(); quality != 2 {
+		t.Errorf("test tree quality == %f; expected 2", quality)
+	}
+	if order := forest.TreesByQuality(); !reflect.DeepEqual(order, []int{1, 0}) {
+		t.Errorf("TreesByQuality == %v; expected [1 0]", order)
+	}
+	// and after sorting, consulting only the first tree uses the test tree:
+	queryPoint := []byte{6, 0, 0, 0, 0, 0}
+	result, _ := forest.SortedByQuality().FindPointWithOptions(context.Background(), queryPoint, QueryOptions{NumTrees: 1})
+	if !reflect.DeepEqual(result.Indices, map[int32]bool{0: true}) || result.TreesConsulted != 1 {
+		t.Errorf("result == %+v; expected {map[0:true] 1}", result)
+	}
+	// or we can pick trees explicitly:
+	result, _ = forest.FindPointWithOptions(context.Background(), queryPoint, QueryOptions{TreeIDs: []int{0}})
+	if !reflect.DeepEqual(result.Indices, map[int32]bool{0: true, 1: true}) || result.TreesConsulted != 1 {
+		t.Errorf("result == %+v; expected {map[0:true 1:true] 1}", result)
+	}
+}
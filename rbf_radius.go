@@ -0,0 +1,111 @@
+package rbf
+
+// Radius (range) queries: all training points within some distance of the query point.
+//
+// Unlike the nearest-neighbor queries this is exact. Every tree indexes every training point, so
+// a single tree suffices: we walk it depth-first, keeping track of the bounding box of the current
+// subtree (the split values on the path to it tell us the range of each feature that was split
+// on, since training and `Insert` put every row on the side its values route to), and skip any
+// subtree whose box is too far away. Pruning needs a `MonotoneDistance`; with
+// any other metric we have to look at every point.
+
+// Find every training point within the given radius (inclusive) of the query point, using the
+// given metric (or the forest's metric if that's nil). Results are sorted by increasing distance,
+// with ties broken by index.
+func (forest RandomBinaryForest) FindWithinRadius(queryPoint []byte, radius float64, distance Distance) ([]Neighbor, error) {
+	if forest.featureArray == nil {
+		return nil, ErrNoTrainingData
+	}
+	if distance == nil {
+		distance = forest.metric()
+	}
+	if len(forest.Trees) == 0 {
+		return nil, nil
+	}
+	_, monotone := distance.(MonotoneDistance)
+	search := radiusSearch{
+		tree:         forest.Trees[0],
+		featureArray: forest.featureArray,
+		distance:     distance,
+		monotone:     monotone,
+		radius:       radius,
+		queryPoint:   queryPoint,
+		lowerBounds:  make([]int32, len(queryPoint)),
+		upperBounds:  make([]int32, len(queryPoint)),
+		closest:      append([]byte{}, queryPoint...),
+	}
+	for i := range search.upperBounds {
+		search.upperBounds[i] = max_feature_value
+	}
+	search.visit(0)
+	sortNeighbors(search.results)
+	return search.results, nil
+}
+
+type radiusSearch struct {
+	tree         RandomBinaryTree
+	featureArray [][]byte
+	distance     Distance
+	monotone     bool
+	radius       float64
+	queryPoint   []byte
+
+	// Bounding box of the current subtree, and the point in it closest to the query point
+	// (i.e. the query point clamped to the box).
+	lowerBounds []int32
+	upperBounds []int32
+	closest     []byte
+
+	results []Neighbor
+}
+
+func (search *radiusSearch) visit(arrayPos int32) {
+	if search.tree.isLeaf(arrayPos) {
+		for _, index := range search.tree.leafIndices(arrayPos) {
+			if dist := search.distance.Distance(search.queryPoint, search.featureArray[index]); dist <= search.radius {
+				search.results = append(search.results, Neighbor{index, dist})
+			}
+		}
+		return
+	}
+
+	featureNum, splitValue := search.tree.split(arrayPos)
+	left, right := search.tree.children(arrayPos)
+	// left subtree has feature <= splitValue, right subtree has feature > splitValue
+	oldLower, oldUpper := search.lowerBounds[featureNum], search.upperBounds[featureNum]
+	if splitValue < oldUpper {
+		search.upperBounds[featureNum] = splitValue
+	}
+	search.visitIfClose(left, featureNum)
+	search.upperBounds[featureNum] = oldUpper
+	if splitValue+1 > oldLower {
+		search.lowerBounds[featureNum] = splitValue + 1
+	}
+	search.visitIfClose(right, featureNum)
+	search.lowerBounds[featureNum] = oldLower
+}
+
+// Visit a subtree unless its bounding box (which has just changed in feature featureNum) rules it out.
+func (search *radiusSearch) visitIfClose(arrayPos int32, featureNum int32) {
+	lower, upper := search.lowerBounds[featureNum], search.upperBounds[featureNum]
+	if lower > upper {
+		return // empty box
+	}
+	if !search.monotone {
+		search.visit(arrayPos)
+		return
+	}
+
+	oldClosest := search.closest[featureNum]
+	clamped := int32(search.queryPoint[featureNum])
+	if clamped < lower {
+		clamped = lower
+	} else if clamped > upper {
+		clamped = upper
+	}
+	search.closest[featureNum] = byte(clamped)
+	if search.distance.Distance(search.queryPoint, search.closest) <= search.radius {
+		search.visit(arrayPos)
+	}
+	search.closest[featureNum] = oldClosest
+}
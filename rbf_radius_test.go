@@ -0,0 +1,70 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindWithinRadius(t *testing.T) {
+	// given a forest over a grid of points:
+	var points [][]byte
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			points = append(points, []byte{byte(x * 10), byte(y * 7), byte((x * y) % 13)})
+		}
+	}
+	forest := TrainForest(points, 2, 6, 4, 2)
+	queryPoint := []byte{42, 30, 5}
+
+	for _, name := range []string{"l1", "l2", "hamming", "chebyshev", "cosine"} {
+		distance, _ := LookupDistance(name)
+		for _, radius := range []float64{0, 0.05, 2, 15, 40} {
+			// when:
+			actual, err := forest.FindWithinRadius(queryPoint, radius, distance)
+			// then we get exactly what brute force gets:
+			if err != nil {
+				t.Fatalf("FindWithinRadius returned error %v", err)
+			}
+			var expected []Neighbor
+			for i, point := range points {
+				if dist := distance.Distance(queryPoint, point); dist <= radius {
+					expected = append(expected, Neighbor{int32(i), dist})
+				}
+			}
+			sortNeighbors(expected)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s, radius %f: FindWithinRadius == %v; expected %v", name, radius, actual, expected)
+			}
+		}
+	}
+}
+
+func TestFindWithinRadiusFindsEveryTrainingPointAtRadiusZero(t *testing.T) {
+	points := newRandomPoints(200, 4, 1, 2)
+	for _, leafSize := range []int32{1, 4} {
+		for _, layout := range []NodeLayout{ImplicitLayout, CompactLayout} {
+			// given a forest trained with each leaf size and layout:
+			forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(2), WithTreeDepth(12),
+				WithLeafSize(leafSize), WithLayout(layout), WithSeed(1)))
+			for i, point := range points {
+				// when we look for a training point at radius 0:
+				neighbors, _ := forest.FindWithinRadius(point, 0, L1)
+				// then we find it:
+				found := false
+				for _, neighbor := range neighbors {
+					found = found || neighbor == Neighbor{int32(i), 0}
+				}
+				if !found {
+					t.Errorf("leaf size %d, layout %d: FindWithinRadius(points[%d], 0) == %v", leafSize, layout, i, neighbors)
+				}
+			}
+		}
+	}
+}
+
+func TestFindWithinRadiusNeedsTrainingData(t *testing.T) {
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	if _, err := forest.FindWithinRadius([]byte{0, 0}, 1, nil); err != ErrNoTrainingData {
+		t.Errorf("err == %v; expected %v", err, ErrNoTrainingData)
+	}
+}
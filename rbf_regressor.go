@@ -0,0 +1,215 @@
+package rbf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Summary of the training targets in one leaf.
+type LeafStats struct {
+	Mean     float64
+	Variance float64 // population variance, i.e. divided by Count
+	Count    int32
+}
+
+// A forest trained to predict a continuous target: splits are chosen to reduce the variance of
+// the target, and each leaf remembers the mean and variance of its rows' targets. As with a
+// `Classifier`, the Forest can't be changed with `Insert`, `Delete`, `Compact` or `SortedByQuality`.
+type Regressor struct {
+	Forest RandomBinaryForest
+
+	// For each tree: the array positions of its leaves (sorted, so we can binary-search them),
+	// and each leaf's stats (in the same order).
+	leafPositions [][]int32
+	leafStats     [][]LeafStats
+}
+
+// Train a regressor. targets[i] is the target value of featureArray[i]. opts.SplitStrategy and
+// opts.Criterion are ignored (we split on variance reduction instead).
+func TrainRegressor(featureArray [][]byte, targets []float64, opts TrainOptions) (Regressor, error) {
+	if err := opts.validate(featureArray); err != nil {
+		return Regressor{}, err
+	}
+	if opts.ColumnMajor {
+		return Regressor{}, fmt.Errorf("rbf: ColumnMajor isn't supported for TrainRegressor")
+	}
+	if len(targets) != len(featureArray) {
+		return Regressor{}, fmt.Errorf("rbf: got %d targets for %d training rows", len(targets), len(featureArray))
+	}
+	for i, target := range targets {
+		if math.IsNaN(target) || math.IsInf(target, 0) {
+			return Regressor{}, fmt.Errorf("rbf: target %f (row %d) isn't a finite number", target, i)
+		}
+	}
+
+	forest := trainForest(featureArray, opts, treeTrainingParams{targets: targets})
+	forest.frozen = true
+	regressor := Regressor{Forest: forest}
+	for _, tree := range forest.Trees {
+		positions, stats := tree.leafTargetStats(targets)
+		regressor.leafPositions = append(regressor.leafPositions, positions)
+		regressor.leafStats = append(regressor.leafStats, stats)
+	}
+	return regressor, nil
+}
+
+func (tree RandomBinaryTree) leafTargetStats(targets []float64) ([]int32, []LeafStats) {
+	positions := tree.sortedLeafPositions()
+	stats := make([]LeafStats, len(positions))
+	for i, arrayPos := range positions {
+		rows := tree.leafIndices(arrayPos)
+		if len(rows) == 0 {
+			continue
+		}
+		var sum, sumSquaredDiffs float64
+		for _, rowNum := range rows {
+			sum += targets[rowNum]
+		}
+		mean := sum / float64(len(rows))
+		for _, rowNum := range rows {
+			sumSquaredDiffs += (targets[rowNum] - mean) * (targets[rowNum] - mean)
+		}
+		stats[i] = LeafStats{mean, sumSquaredDiffs / float64(len(rows)), int32(len(rows))}
+	}
+	return positions, stats
+}
+
+// The stats of the query point's leaf in each tree (in tree order).
+func (regressor Regressor) LeafStats(point []byte) []LeafStats {
+	stats := make([]LeafStats, len(regressor.Forest.Trees))
+	for treeNum, tree := range regressor.Forest.Trees {
+		stats[treeNum] = regressor.leafStats[treeNum][leafNumber(regressor.leafPositions[treeNum], tree.findLeaf(point))]
+	}
+	return stats
+}
+
+// Predict the target for a point.
+// Returns:
+// - the estimate: the average over trees of the mean target in the point's leaf
+// - the spread: the standard deviation of those per-tree means (so how much the trees disagree)
+// Trees whose leaf is empty are skipped; if they all are, both values are NaN.
+func (regressor Regressor) Predict(point []byte) (float64, float64) {
+	var sum, sumSquares float64
+	numTrees := 0
+	for _, stats := range regressor.LeafStats(point) {
+		if stats.Count == 0 {
+			continue
+		}
+		sum += stats.Mean
+		sumSquares += stats.Mean * stats.Mean
+		numTrees += 1
+	}
+	if numTrees == 0 {
+		return math.NaN(), math.NaN()
+	}
+	mean := sum / float64(numTrees)
+	variance := sumSquares/float64(numTrees) - mean*mean
+	return mean, math.Sqrt(math.Max(variance, 0))
+}
+
+//######################################################################################################################
+// Training: variance-reduction splits.
+//######################################################################################################################
+
+// Like `getSingleFeatureFrequencies`, but also the sum and sum of squares of the targets of the
+// rows with each feature value.
+type targetHistogram struct {
+	counts     []int32
+	sums       []float64
+	sumSquares []float64
+}
+
+func getSingleFeatureTargetSums(rowIndex []int32, featureArray [][]byte, targets []float64, featureNum, indexStart, indexEnd int32) targetHistogram {
+	histogram := targetHistogram{
+		counts:     make([]int32, max_feature_value+1),
+		sums:       make([]float64, max_feature_value+1),
+		sumSquares: make([]float64, max_feature_value+1),
+	}
+	for rowNum := indexStart; rowNum < indexEnd; rowNum++ {
+		featureValue := featureArray[rowIndex[rowNum]][featureNum]
+		target := targets[rowIndex[rowNum]]
+		histogram.counts[featureValue] += 1
+		histogram.sums[featureValue] += target
+		histogram.sumSquares[featureValue] += target * target
+	}
+	return histogram
+}
+
+// Find the feature and split value that give the lowest total squared error (i.e. variance
+// times count) over the two children.
+// Returns: the index into targetSums of the best feature, and the split value. If no feature
+// can be split at all we return a degenerate split (and `splitNode` tries again).
+func getLowestVarianceFeature(targetSums []targetHistogram) (int32, byte) {
+	var bestFeatureNum int32
+	bestSplitValue := int32(max_feature_value)
+	bestError := math.Inf(1)
+	squaredError := func(count int32, sum, sumSquares float64) float64 {
+		return sumSquares - sum*sum/float64(count)
+	}
+	for i, histogram := range targetSums {
+		var totalCount int32
+		var totalSum, totalSumSquares float64
+		for value := range histogram.counts {
+			totalCount += histogram.counts[value]
+			totalSum += histogram.sums[value]
+			totalSumSquares += histogram.sumSquares[value]
+		}
+		// Sweep the split value up, moving each value's rows from right to left:
+		var leftCount int32
+		var leftSum, leftSumSquares float64
+		for value := int32(0); value < max_feature_value; value++ {
+			leftCount += histogram.counts[value]
+			leftSum += histogram.sums[value]
+			leftSumSquares += histogram.sumSquares[value]
+			if leftCount == 0 || histogram.counts[value] == 0 {
+				continue
+			}
+			if leftCount == totalCount {
+				break
+			}
+			totalError := squaredError(leftCount, leftSum, leftSumSquares) +
+				squaredError(totalCount-leftCount, totalSum-leftSum, totalSumSquares-leftSumSquares)
+			if totalError < bestError {
+				bestError = totalError
+				bestFeatureNum = int32(i)
+				bestSplitValue = value
+			}
+		}
+	}
+	return bestFeatureNum, byte(bestSplitValue)
+}
+
+//######################################################################################################################
+// Read/write.
+//######################################################################################################################
+
+// Write the forest (as `RandomBinaryForest.WriteToWriter` does) followed by the leaf stats.
+func (regressor Regressor) WriteToWriter(writer io.Writer) {
+	regressor.Forest.WriteToWriter(writer)
+	for treeNum := range regressor.Forest.Trees {
+		err := binary.Write(writer, binary.LittleEndian, int32(len(regressor.leafPositions[treeNum])))
+		check(err)
+		unsafelyWriteIntSlice(writer, regressor.leafPositions[treeNum])
+		err = binary.Write(writer, binary.LittleEndian, regressor.leafStats[treeNum])
+		check(err)
+	}
+}
+
+func ReadRegressorFromReader(reader io.Reader) Regressor {
+	forest := ReadForestFromReader(reader)
+	forest.frozen = true
+	regressor := Regressor{Forest: forest}
+	for range forest.Trees {
+		var numLeaves int32
+		err := binary.Read(reader, binary.LittleEndian, &numLeaves)
+		check(err)
+		regressor.leafPositions = append(regressor.leafPositions, unsafelyReadIntSlice(reader, numLeaves))
+		stats := make([]LeafStats, numLeaves)
+		err = binary.Read(reader, binary.LittleEndian, stats)
+		check(err)
+		regressor.leafStats = append(regressor.leafStats, stats)
+	}
+	return regressor
+}
@@ -0,0 +1,93 @@
+package rbf
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Two noise features and one feature that determines the target (target = 10 * feature 0).
+func newRegressorTestData() ([][]byte, []float64) {
+	points := newRandomPoints(400, 3, 1, 11)
+	targets := make([]float64, len(points))
+	for i, point := range points {
+		targets[i] = 10 * float64(point[0])
+	}
+	return points, targets
+}
+
+func TestRegressorPredict(t *testing.T) {
+	// given a regressor:
+	points, targets := newRegressorTestData()
+	regressor, err := TrainRegressor(points, targets, NewTrainOptions(WithNumTrees(5), WithTreeDepth(10), WithLeafSize(4),
+		WithNumFeaturesToCompare(3), WithSeed(1)))
+	if err != nil {
+		t.Fatalf("TrainRegressor returned error %v", err)
+	}
+	// when we predict points the target of which only depends on the informative feature:
+	for _, point := range [][]byte{{20, 100, 7}, {128, 3, 250}, {240, 60, 60}} {
+		estimate, spread := regressor.Predict(point)
+		// then the estimate is close to the truth and the spread is small:
+		expected := 10 * float64(point[0])
+		if math.Abs(estimate-expected) > 100 || spread < 0 || spread > 100 {
+			t.Errorf("Predict(%v) == (%f, %f); expected (~%f, small)", point, estimate, spread, expected)
+		}
+	}
+}
+
+func TestRegressorSplitsOnInformativeFeature(t *testing.T) {
+	// given target sums for two candidate features, where only the second separates the targets:
+	newHistogram := func(targetsByValue map[int][]float64) targetHistogram {
+		histogram := targetHistogram{make([]int32, max_feature_value+1), make([]float64, max_feature_value+1),
+			make([]float64, max_feature_value+1)}
+		for value, targets := range targetsByValue {
+			for _, target := range targets {
+				histogram.counts[value] += 1
+				histogram.sums[value] += target
+				histogram.sumSquares[value] += target * target
+			}
+		}
+		return histogram
+	}
+	noise := newHistogram(map[int][]float64{10: {1, 100}, 20: {1, 100}})
+	informative := newHistogram(map[int][]float64{10: {1, 1}, 20: {100, 100}})
+	// when we look for the lowest-variance split:
+	featureIndex, splitValue := getLowestVarianceFeature([]targetHistogram{noise, informative})
+	// then we split the informative feature between 10 and 20:
+	if featureIndex != 1 || splitValue < 10 || splitValue >= 20 {
+		t.Errorf("(featureIndex, splitValue) == (%d, %d); expected (1, [10, 20))", featureIndex, splitValue)
+	}
+}
+
+func TestRegressorErrors(t *testing.T) {
+	points := [][]byte{{0}, {1}}
+	runOneTest := func(targets []float64, expErrSubstring string) {
+		_, err := TrainRegressor(points, targets, NewTrainOptions())
+		if err == nil || !strings.Contains(err.Error(), expErrSubstring) {
+			t.Errorf("err == %v; expected error containing %q", err, expErrSubstring)
+		}
+	}
+	runOneTest([]float64{0}, "targets")
+	runOneTest([]float64{0, math.NaN()}, "finite")
+	runOneTest([]float64{math.Inf(1), 0}, "finite")
+}
+
+func TestReadAndWriteRegressor(t *testing.T) {
+	// given a trained regressor:
+	points, targets := newRegressorTestData()
+	regressor, _ := TrainRegressor(points, targets, NewTrainOptions(WithNumTrees(3), WithTreeDepth(6), WithLeafSize(8)))
+	// when we write it and read it back:
+	var builder strings.Builder
+	regressor.WriteToWriter(&builder)
+	regressorIn := ReadRegressorFromReader(strings.NewReader(builder.String()))
+	// then it has the same leaf stats:
+	if !reflect.DeepEqual(regressorIn.leafStats, regressor.leafStats) {
+		t.Errorf("deserialized regressor not the same as original regressor")
+	}
+	for _, point := range points[:20] {
+		if !reflect.DeepEqual(regressorIn.LeafStats(point), regressor.LeafStats(point)) {
+			t.Errorf("LeafStats(%v) changed after serialization", point)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package rbf
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
 // Query the forest for a single point. Returns indices into the training feature-array
 // (since the caller/wrapper might have different things they want to do with this).
 // Since multiple trees might return the same result points, this method dedups the results.
@@ -19,31 +26,241 @@ func (forest RandomBinaryForest) FindPointDedupResults(queryPoint []byte) map[in
 // Returns: for each tree in the forest, a slice of indices into the training feature-array
 // (since the caller/wrapper might have different things they want to do with this).
 // This method does not dedup results -- each tree's results are a different slice.
+// The results are copies, so the caller is free to modify them (see `Searcher` to avoid allocating).
 func (forest RandomBinaryForest) FindPointAllResults(queryPoint []byte) (count int, results [][]int32) {
 	results = make([][]int32, len(forest.Trees))
 	for i, tree := range forest.Trees {
 		results[i] = tree.findPoint(queryPoint)
 		count += len(results[i])
 	}
+	// copy everything into one backing array
+	allResults := make([]int32, 0, count)
+	for i, treeResults := range results {
+		start := len(allResults)
+		allResults = append(allResults, treeResults...)
+		results[i] = allResults[start:len(allResults):len(allResults)]
+	}
 	return
 }
 
+// Options for `FindPointWithOptions`, mostly to keep query latency within a budget.
+// The zero value means no limits (i.e. the same as `FindPointDedupResults`).
+type QueryOptions struct {
+	// Stop visiting trees once at least this many unique candidates have been collected.
+	MaxCandidates int
+	// Stop visiting trees once this much time has passed. (A deadline on the context passed to
+	// `FindPointWithOptions` works too.)
+	TimeBudget time.Duration
+	// Only consult the first NumTrees trees. Use `SortedByQuality` on the forest first to make
+	// these the best ones.
+	NumTrees int
+	// Only consult these trees, in this order. Overrides NumTrees. Each has to be a valid index
+	// into the forest's Trees.
+	TreeIDs []int
+}
+
+// Results of `FindPointWithOptions`.
+type QueryResult struct {
+	// Deduped indices into the training feature-array (same as `FindPointDedupResults`).
+	Indices map[int32]bool
+	// How many trees were actually consulted before we stopped. Less than the number of trees
+	// selected (all of them, or per NumTrees or TreeIDs) iff a budget ran out.
+	TreesConsulted int
+}
+
+// Query the forest for a single point, stopping early (i.e. without consulting every tree) if we
+// collect enough candidates, run out of time, or the context is done. Running out of budget
+// isn't an error; check `TreesConsulted` to see how far we got. Returns an error (and doesn't
+// query anything) if TreeIDs has an invalid tree.
+func (forest RandomBinaryForest) FindPointWithOptions(ctx context.Context, queryPoint []byte, opts QueryOptions) (QueryResult, error) {
+	trees, err := forest.selectTrees(opts)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	var deadline time.Time
+	if opts.TimeBudget > 0 {
+		deadline = time.Now().Add(opts.TimeBudget)
+	}
+
+	result := QueryResult{Indices: make(map[int32]bool)}
+	for _, tree := range trees {
+		if opts.MaxCandidates > 0 && len(result.Indices) >= opts.MaxCandidates {
+			break
+		}
+		if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+			break
+		}
+		for _, index := range tree.findPoint(queryPoint) {
+			result.Indices[index] = true
+		}
+		result.TreesConsulted += 1
+	}
+	return result, nil
+}
+
+// The trees to consult, per `QueryOptions.NumTrees` and `QueryOptions.TreeIDs`.
+func (forest RandomBinaryForest) selectTrees(opts QueryOptions) ([]RandomBinaryTree, error) {
+	if opts.TreeIDs != nil {
+		trees := make([]RandomBinaryTree, len(opts.TreeIDs))
+		for i, treeID := range opts.TreeIDs {
+			if treeID < 0 || treeID >= len(forest.Trees) {
+				return nil, fmt.Errorf("rbf: TreeIDs has tree %d; the forest has %d trees", treeID, len(forest.Trees))
+			}
+			trees[i] = forest.Trees[treeID]
+		}
+		return trees, nil
+	}
+	if opts.NumTrees > 0 && opts.NumTrees < len(forest.Trees) {
+		return forest.Trees[:opts.NumTrees], nil
+	}
+	return forest.Trees, nil
+}
+
 // A "point" is a feature-array. Search for one point in this tree.
 func (tree RandomBinaryTree) findPoint(queryPoint []byte) []int32 {
-	arrayPos := int32(0)
+	return tree.leafIndices(tree.findLeaf(queryPoint))
+}
+
+// Follow the single root-to-leaf path for a point and return the leaf's position in the tree arrays.
+func (tree RandomBinaryTree) findLeaf(queryPoint []byte) int32 {
+	arrayPos, _ := tree.findLeafAndDepth(queryPoint)
+	return arrayPos
+}
+
+// Same as `findLeaf`, but also return the leaf's depth (the root is at depth 0).
+func (tree RandomBinaryTree) findLeafAndDepth(queryPoint []byte) (int32, int) {
+	arrayPos, depth := int32(0), 0
 	first := tree.treeFirst[arrayPos]
 	// the condition checks if it's an internal node (== 0) or a leaf (== -1):
 	for first>>high_bit == 0 {
 		// internal node, so first (the entry in tree.treeFirst) is a feature-number and
 		// the entry in tree.treeSecond is the feature-value at which to split:
+		left, right := tree.children(arrayPos)
 		if int32(queryPoint[first]) <= tree.treeSecond[arrayPos] {
-			arrayPos = (2 * arrayPos) + 1
+			arrayPos = left
 		} else {
-			arrayPos = (2 * arrayPos) + 2
+			arrayPos = right
 		}
 		first = tree.treeFirst[arrayPos]
+		depth += 1
+	}
+	return arrayPos, depth
+}
+
+//######################################################################################################################
+// Tree navigation. Anything that walks a tree should go through these rather than doing the
+// array arithmetic (see comments in the RandomBinaryTree definition) itself.
+//######################################################################################################################
+func (tree RandomBinaryTree) isLeaf(arrayPos int32) bool {
+	return tree.treeFirst[arrayPos]>>high_bit != 0
+}
+
+// Whether a node (at the given depth) is as deep as training allows, i.e. it couldn't have
+// children. Training makes these nodes leaves no matter how many rows they have.
+// - implicit layout: its children wouldn't fit in the tree arrays
+// - compact layout: it's at depth TreeDepth-1
+func (tree RandomBinaryTree) isDepthLimited(arrayPos int32, depth int) bool {
+	if tree.treeChild != nil {
+		return depth+1 >= int(tree.maxDepth)
 	}
-	// found a leaf; get values and return
-	indexStart, indexEnd := high_bit_1^first, high_bit_1^tree.treeSecond[arrayPos]
+	return 2*int(arrayPos)+2 >= len(tree.treeFirst)
+}
+
+// Positions of the left and right children of an internal node.
+func (tree RandomBinaryTree) children(arrayPos int32) (int32, int32) {
+	if tree.treeChild != nil {
+		return tree.treeChild[arrayPos], tree.treeChild[arrayPos] + 1
+	}
+	return (2 * arrayPos) + 1, (2 * arrayPos) + 2
+}
+
+// Feature-number and split-value of an internal node.
+func (tree RandomBinaryTree) split(arrayPos int32) (int32, int32) {
+	return tree.treeFirst[arrayPos], tree.treeSecond[arrayPos]
+}
+
+// Start and end of a leaf's view into rowIndex.
+func (tree RandomBinaryTree) leafRange(arrayPos int32) (int32, int32) {
+	return high_bit_1 ^ tree.treeFirst[arrayPos], high_bit_1 ^ tree.treeSecond[arrayPos]
+}
+
+// Indices into the training feature-array of the rows in a leaf. This is a view into rowIndex,
+// so callers must not modify it.
+func (tree RandomBinaryTree) leafIndices(arrayPos int32) []int32 {
+	indexStart, indexEnd := tree.leafRange(arrayPos)
 	return tree.rowIndex[indexStart:indexEnd]
 }
+
+// Call f on every leaf in the tree, in depth-first (left-to-right) order.
+func (tree RandomBinaryTree) forEachLeaf(f func(arrayPos int32)) {
+	var visit func(arrayPos int32)
+	visit = func(arrayPos int32) {
+		if tree.isLeaf(arrayPos) {
+			f(arrayPos)
+			return
+		}
+		left, right := tree.children(arrayPos)
+		visit(left)
+		visit(right)
+	}
+	visit(0)
+}
+
+// Number of training rows the tree indexes (i.e. the total size of its leaves).
+func (tree RandomBinaryTree) numRows() int {
+	numRows := 0
+	tree.forEachLeaf(func(arrayPos int32) { numRows += len(tree.leafIndices(arrayPos)) })
+	return numRows
+}
+
+// One more than the largest row index in the tree (so it's the length a slice needs to be to
+// hold something per row). This is the number of rows unless some have been deleted.
+func (tree RandomBinaryTree) rowIndexBound() int {
+	bound := 0
+	tree.forEachLeaf(func(arrayPos int32) {
+		for _, index := range tree.leafIndices(arrayPos) {
+			if int(index) >= bound {
+				bound = int(index) + 1
+			}
+		}
+	})
+	return bound
+}
+
+// Make a node a leaf with the given view into rowIndex.
+func (tree *RandomBinaryTree) setLeaf(arrayPos, indexStart, indexEnd int32) {
+	tree.treeFirst[arrayPos], tree.treeSecond[arrayPos] = high_bit_1^indexStart, high_bit_1^indexEnd
+}
+
+// Make a node an internal node that splits on the given feature and value.
+func (tree *RandomBinaryTree) setSplit(arrayPos, featureNum, splitValue int32) {
+	tree.treeFirst[arrayPos], tree.treeSecond[arrayPos] = featureNum, splitValue
+}
+
+// Make room for a node's children and return their positions. The node itself still has to be
+// made an internal node with `setSplit`. Pre-req: the node isn't depth-limited (see
+// `isDepthLimited`), so with the implicit layout its children already have room.
+func (tree *RandomBinaryTree) addChildren(arrayPos int32) (int32, int32) {
+	if tree.treeChild != nil {
+		// compact: append the pair to the end
+		tree.treeChild[arrayPos] = int32(len(tree.treeFirst))
+		tree.treeFirst = append(tree.treeFirst, 0, 0)
+		tree.treeSecond = append(tree.treeSecond, 0, 0)
+		tree.treeChild = append(tree.treeChild, 0, 0)
+	}
+	return tree.children(arrayPos)
+}
+
+// Positions of all the leaves, in increasing order. Models that keep per-leaf data (e.g. the
+// Classifier) store it in this order and find a leaf's entry with `leafNumber`.
+func (tree RandomBinaryTree) sortedLeafPositions() []int32 {
+	var positions []int32
+	tree.forEachLeaf(func(arrayPos int32) { positions = append(positions, arrayPos) })
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	return positions
+}
+
+// The index of a leaf's position in the output of `sortedLeafPositions`.
+func leafNumber(sortedLeafPositions []int32, arrayPos int32) int32 {
+	return int32(sort.Search(len(sortedLeafPositions), func(i int) bool { return sortedLeafPositions[i] >= arrayPos }))
+}
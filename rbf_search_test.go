@@ -1,13 +1,14 @@
 package rbf
 
 import (
+	"context"
 	"testing"
 )
 
 func TestFindPoint(t *testing.T) {
 	// given:
 	trees := []RandomBinaryTree{NewTestTree(), NewTestTree()}
-	forest := RandomBinaryForest{trees}
+	forest := RandomBinaryForest{Trees: trees}
 	queryPoint := []byte{6, 0, 0, 0, 0, 0} // initial slice of followgrams for "aaaa"
 	// when:
 	queryResultIndices := forest.FindPointDedupResults(queryPoint)
@@ -16,3 +17,40 @@ func TestFindPoint(t *testing.T) {
 		t.Errorf("queryResultIndices == %v, expected %v", queryResultIndices, map[int32]bool{0: true})
 	}
 }
+
+func TestFindPointWithOptions(t *testing.T) {
+	// given three trees that return overlapping candidates:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{
+		newSingleLeafTree([]int32{0, 1}),
+		newSingleLeafTree([]int32{2, 1}),
+		newSingleLeafTree([]int32{3, 2}),
+	}}
+	queryPoint := []byte{0}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runOneTest := func(ctx context.Context, opts QueryOptions, expCandidates, expTrees int) {
+		result, err := forest.FindPointWithOptions(ctx, queryPoint, opts)
+		if err != nil {
+			t.Fatalf("opts == %+v: FindPointWithOptions returned error %v", opts, err)
+		}
+		if len(result.Indices) != expCandidates || result.TreesConsulted != expTrees {
+			t.Errorf("opts == %+v: (candidates, trees consulted) == (%d, %d); expected (%d, %d)",
+				opts, len(result.Indices), result.TreesConsulted, expCandidates, expTrees)
+		}
+	}
+
+	// with no limits we consult every tree:
+	runOneTest(context.Background(), QueryOptions{}, 4, 3)
+	// we stop as soon as we have enough candidates (after the 2nd tree here):
+	runOneTest(context.Background(), QueryOptions{MaxCandidates: 3}, 3, 2)
+	// and a done context means we don't even start:
+	runOneTest(cancelled, QueryOptions{}, 0, 0)
+
+	// and invalid tree IDs are an error:
+	for _, treeIDs := range [][]int{{0, 3}, {-1}} {
+		if _, err := forest.FindPointWithOptions(context.Background(), queryPoint, QueryOptions{TreeIDs: treeIDs}); err == nil {
+			t.Errorf("TreeIDs == %v: expected an error", treeIDs)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package rbf
+
+// A Searcher queries a forest without allocating in the steady state: it owns scratch space that
+// is reused from one query to the next. Results are copied out of the trees into that scratch
+// space, so they're only valid until the next query on the same Searcher. Copy them if you need
+// to keep them.
+//
+// A Searcher is not safe for concurrent use; create one per goroutine (they're cheap apart from
+// the dedup bitset, which has one entry per training row).
+type Searcher struct {
+	forest RandomBinaryForest
+
+	// For dedup: seen[i] == generation iff row i has been seen in the current query. Bumping the
+	// generation "clears" the whole thing in O(1).
+	seen       []uint32
+	generation uint32
+
+	// Result buffers
+	dedupResults []int32
+	allResults   []int32
+	treeEnds     []int
+	treeResults  [][]int32
+}
+
+func (forest RandomBinaryForest) NewSearcher() *Searcher {
+	numRows := 0
+	if len(forest.Trees) > 0 {
+		numRows = forest.Trees[0].rowIndexBound()
+	}
+	return &Searcher{
+		forest:      forest,
+		seen:        make([]uint32, numRows),
+		treeEnds:    make([]int, len(forest.Trees)),
+		treeResults: make([][]int32, len(forest.Trees)),
+	}
+}
+
+// Same as `RandomBinaryForest.FindPointDedupResults`, but returns a slice (in no particular order)
+// instead of a set.
+func (searcher *Searcher) FindPointDedupResults(queryPoint []byte) []int32 {
+	searcher.nextGeneration()
+	results := searcher.dedupResults[:0]
+	for _, tree := range searcher.forest.Trees {
+		for _, index := range tree.findPoint(queryPoint) {
+			if searcher.markSeen(index) {
+				results = append(results, index)
+			}
+		}
+	}
+	searcher.dedupResults = results
+	return results
+}
+
+// Same as `RandomBinaryForest.FindPointAllResults`.
+func (searcher *Searcher) FindPointAllResults(queryPoint []byte) (count int, results [][]int32) {
+	allResults := searcher.allResults[:0]
+	for i, tree := range searcher.forest.Trees {
+		allResults = append(allResults, tree.findPoint(queryPoint)...)
+		searcher.treeEnds[i] = len(allResults)
+	}
+	searcher.allResults = allResults
+
+	// Now slice it up. (We can't do this as we go because append might move allResults.)
+	results = searcher.treeResults
+	for i, end := range searcher.treeEnds {
+		results[i] = allResults[count:end:end]
+		count = end
+	}
+	return
+}
+
+func (searcher *Searcher) nextGeneration() {
+	searcher.generation += 1
+	if searcher.generation == 0 {
+		// wrapped around, so old stamps could look current; really clear it
+		for i := range searcher.seen {
+			searcher.seen[i] = 0
+		}
+		searcher.generation = 1
+	}
+}
+
+// Mark a row as seen in this generation. Returns true if it wasn't already seen.
+func (searcher *Searcher) markSeen(index int32) bool {
+	if int(index) >= len(searcher.seen) {
+		// the forest has grown since we were created
+		searcher.seen = append(searcher.seen, make([]uint32, int(index)+1-len(searcher.seen))...)
+	}
+	if searcher.seen[index] == searcher.generation {
+		return false
+	}
+	searcher.seen[index] = searcher.generation
+	return true
+}
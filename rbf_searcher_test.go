@@ -0,0 +1,64 @@
+package rbf
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSearcher(t *testing.T) {
+	// given a forest and a searcher on it:
+	points := [][]byte{{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7}}
+	forest := TrainForest(points, 4, 3, 2, 1)
+	searcher := forest.NewSearcher()
+
+	for _, queryPoint := range [][]byte{{0}, {3}, {3}, {7}} {
+		// when/then the searcher's results match the forest's:
+		count, results := searcher.FindPointAllResults(queryPoint)
+		expCount, expResults := forest.FindPointAllResults(queryPoint)
+		if count != expCount || !reflect.DeepEqual(results, expResults) {
+			t.Errorf("searcher.FindPointAllResults(%v) == (%d, %v); expected (%d, %v)", queryPoint, count, results, expCount, expResults)
+		}
+		dedupResults := append([]int32{}, searcher.FindPointDedupResults(queryPoint)...)
+		sort.Slice(dedupResults, func(i, j int) bool { return dedupResults[i] < dedupResults[j] })
+		var expDedupResults []int32
+		for index := range forest.FindPointDedupResults(queryPoint) {
+			expDedupResults = append(expDedupResults, index)
+		}
+		sort.Slice(expDedupResults, func(i, j int) bool { return expDedupResults[i] < expDedupResults[j] })
+		if !reflect.DeepEqual(dedupResults, expDedupResults) {
+			t.Errorf("searcher.FindPointDedupResults(%v) == %v; expected %v", queryPoint, dedupResults, expDedupResults)
+		}
+	}
+}
+
+func TestSearcherDoesNotAllocate(t *testing.T) {
+	// given a warmed-up searcher:
+	points := [][]byte{{0}, {1}, {2}, {3}, {4}, {5}, {6}, {7}}
+	searcher := TrainForest(points, 4, 3, 2, 1).NewSearcher()
+	queryPoint := []byte{3}
+	searcher.FindPointAllResults(queryPoint)
+	searcher.FindPointDedupResults(queryPoint)
+	// when/then:
+	allocs := testing.AllocsPerRun(100, func() {
+		searcher.FindPointAllResults(queryPoint)
+		searcher.FindPointDedupResults(queryPoint)
+	})
+	if allocs != 0 {
+		t.Errorf("searcher allocated %f times per query; expected 0", allocs)
+	}
+}
+
+func TestResultsAreCopies(t *testing.T) {
+	// given:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree()}}
+	queryPoint := []byte{6, 0, 0, 0, 0, 0}
+	// when we scribble on the results:
+	_, results := forest.FindPointAllResults(queryPoint)
+	results[0][0] = 99
+	forest.NewSearcher().FindPointDedupResults(queryPoint)[0] = 99
+	// then the tree is unchanged:
+	if _, results = forest.FindPointAllResults(queryPoint); results[0][0] != 0 {
+		t.Errorf("results == %v after modifying earlier results; expected [[0]]", results)
+	}
+}
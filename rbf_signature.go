@@ -0,0 +1,35 @@
+package rbf
+
+// Leaf-ID signatures: encode a point as the leaf it reaches in each tree. Two points' signatures
+// agree in exactly the trees where they'd share a leaf, so comparing signatures gives the same
+// answer as `Proximity` without needing the forest. This is like a MinHash signature, and lets
+// you store compact per-record signatures and compare them offline.
+//
+// A signature is only comparable with signatures from the same forest (leaf IDs are positions in
+// that forest's tree arrays). Changing the forest changes some leaves: `Insert` splits leaves and
+// `Compact` merges them, and points in those leaves then get different signatures, so re-encode
+// stored points after either one (or keep using the forest as it was when they were encoded).
+
+// Return the leaf array-position reached by the point in each tree.
+func (forest RandomBinaryForest) Encode(point []byte) []int32 {
+	signature := make([]int32, len(forest.Trees))
+	for i, tree := range forest.Trees {
+		signature[i] = tree.findLeaf(point)
+	}
+	return signature
+}
+
+// The fraction of trees in which two signatures (from `Encode`) have the same leaf.
+// Returns 0 if the signatures are empty or have different lengths (and so can't be from the same forest).
+func SignatureSimilarity(signature1, signature2 []int32) float64 {
+	if len(signature1) == 0 || len(signature1) != len(signature2) {
+		return 0
+	}
+	sameLeafCount := 0
+	for i := range signature1 {
+		if signature1[i] == signature2[i] {
+			sameLeafCount += 1
+		}
+	}
+	return float64(sameLeafCount) / float64(len(signature1))
+}
@@ -0,0 +1,28 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	// given the test tree (left leaf at position 1, right leaf at position 2) and a single-leaf tree:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{NewTestTree(), newSingleLeafTree([]int32{0, 1})}}
+	point1 := []byte{6, 0, 0, 0, 0, 0}
+	point2 := []byte{0, 1, 0, 0, 0, 0}
+	// when:
+	signature1 := forest.Encode(point1)
+	signature2 := forest.Encode(point2)
+	// then:
+	if !reflect.DeepEqual(signature1, []int32{2, 0}) || !reflect.DeepEqual(signature2, []int32{1, 0}) {
+		t.Errorf("signatures == (%v, %v); expected ([2 0], [1 0])", signature1, signature2)
+	}
+	// and signature similarity agrees with proximity:
+	if similarity := SignatureSimilarity(signature1, signature2); similarity != forest.Proximity(point1, point2) {
+		t.Errorf("SignatureSimilarity == %f; expected %f", similarity, forest.Proximity(point1, point2))
+	}
+	// and mismatched signatures aren't similar at all:
+	if similarity := SignatureSimilarity(signature1, []int32{2}); similarity != 0 {
+		t.Errorf("SignatureSimilarity of mismatched signatures == %f; expected 0", similarity)
+	}
+}
@@ -0,0 +1,104 @@
+package rbf
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// How to choose a split at a tree node, given the candidate features (a random subset of
+// `TrainOptions.NumFeaturesToCompare` features).
+//
+// Params:
+// - featureFrequencies[i] is the histogram of candidate feature i over the node's rows, i.e. the
+//   frequency of each integer value in [0, 255] (see `getSingleFeatureFrequencies`)
+// - featureWeightedTotals[i] is the sum of candidate feature i's values
+// - totalCount is the number of rows at this node
+// - rng is the tree's source of randomness (use this, not the global source, so that seeded
+//   training stays reproducible)
+// Returns: the index (into the candidates, not the feature number) of the feature to split on,
+// and the split value: rows with feature-value <= split value go left, the rest go right.
+//
+// If every row would go the same way we try again with other features, so it's fine to return
+// a degenerate split if there's nothing better.
+type SplitStrategy interface {
+	ChooseSplit(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32, rng *rand.Rand) (int32, byte)
+}
+
+// The default: split the feature whose median splits the rows most evenly.
+type MedianSplit struct{}
+
+func (MedianSplit) ChooseSplit(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32, rng *rand.Rand) (int32, byte) {
+	return getSimpleBestFeature(featureFrequencies, featureWeightedTotals, totalCount)
+}
+
+// Split near the median, but prefer features with a large total absolute deviation about the
+// split (see `splitOneFeature`), so noisy data is less likely to land on the wrong side.
+// Only features whose split puts between MinSplitRatio and MaxSplitRatio of the rows on the left
+// are considered (unless there are none). Both must be positive, with
+// 0 < MinSplitRatio < MaxSplitRatio <= 1; leaving either one zero means MIN_SPLIT_RATIO or
+// MAX_SPLIT_RATIO respectively, so a ratio of exactly 0 can't be chosen.
+type MeanAbsoluteDeviationSplit struct {
+	MinSplitRatio float64
+	MaxSplitRatio float64
+}
+
+func (s MeanAbsoluteDeviationSplit) ChooseSplit(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32, rng *rand.Rand) (int32, byte) {
+	minSplitRatio, maxSplitRatio := s.ratios()
+	return getBestFeatureInRange(featureFrequencies, featureWeightedTotals, totalCount, minSplitRatio, maxSplitRatio)
+}
+
+func (s MeanAbsoluteDeviationSplit) ratios() (float64, float64) {
+	minSplitRatio, maxSplitRatio := s.MinSplitRatio, s.MaxSplitRatio
+	if minSplitRatio == 0 {
+		minSplitRatio = MIN_SPLIT_RATIO
+	}
+	if maxSplitRatio == 0 {
+		maxSplitRatio = MAX_SPLIT_RATIO
+	}
+	return minSplitRatio, maxSplitRatio
+}
+
+func (s MeanAbsoluteDeviationSplit) validate() error {
+	if minSplitRatio, maxSplitRatio := s.ratios(); minSplitRatio <= 0 || maxSplitRatio > 1 || minSplitRatio >= maxSplitRatio {
+		return fmt.Errorf("rbf: MeanAbsoluteDeviationSplit ratios must satisfy 0 < MinSplitRatio < MaxSplitRatio <= 1, where zero means the default (got %f, %f)",
+			minSplitRatio, maxSplitRatio)
+	}
+	return nil
+}
+
+// "Extremely randomized" splits: pick a uniformly random split value between each candidate
+// feature's min and max, and use the feature whose random split is most even. Training is faster
+// and the trees are more diverse (so more trees are needed, but they agree less by accident).
+type RandomThresholdSplit struct{}
+
+func (RandomThresholdSplit) ChooseSplit(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32, rng *rand.Rand) (int32, byte) {
+	var bestFeatureNum, bestSplitValue int32
+	bestSplitDiff := int32(-1)
+	for i, freq := range featureFrequencies {
+		minValue, maxValue := int32(0), int32(max_feature_value)
+		for freq[minValue] == 0 && minValue < maxValue {
+			minValue += 1
+		}
+		for freq[maxValue] == 0 && maxValue > minValue {
+			maxValue -= 1
+		}
+		if minValue == maxValue {
+			continue // constant feature, can't split it
+		}
+		splitValue := minValue + rng.Int31n(maxValue-minValue) // so both sides are non-empty
+		var leftCount int32
+		for value := int32(0); value <= splitValue; value++ {
+			leftCount += freq[value]
+		}
+		splitDiff := leftCount - (totalCount - leftCount)
+		if splitDiff < 0 {
+			splitDiff = -splitDiff
+		}
+		if bestSplitDiff < 0 || splitDiff < bestSplitDiff {
+			bestSplitDiff = splitDiff
+			bestFeatureNum = int32(i)
+			bestSplitValue = splitValue
+		}
+	}
+	return bestFeatureNum, byte(bestSplitValue)
+}
@@ -0,0 +1,56 @@
+package rbf
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRandomThresholdSplit(t *testing.T) {
+	// given a constant feature and a feature with values 3 and 9:
+	featureFrequencies := [][]int32{make([]int32, 256), make([]int32, 256)}
+	featureFrequencies[0][5] = 4
+	featureFrequencies[1][3], featureFrequencies[1][9] = 2, 2
+	weightedTotals := []int32{20, 24}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		// when:
+		featureIndex, splitValue := RandomThresholdSplit{}.ChooseSplit(featureFrequencies, weightedTotals, 4, rng)
+		// then we always split the non-constant feature somewhere that separates 3 from 9:
+		if featureIndex != 1 || splitValue < 3 || splitValue >= 9 {
+			t.Errorf("(featureIndex, splitValue) == (%d, %d); expected (1, [3, 9))", featureIndex, splitValue)
+		}
+	}
+}
+
+func TestTrainWithSplitStrategies(t *testing.T) {
+	points := [][]byte{{0, 7}, {1, 6}, {2, 5}, {3, 4}, {4, 3}, {5, 2}, {6, 1}, {7, 0}}
+	for _, strategy := range []SplitStrategy{MedianSplit{}, MeanAbsoluteDeviationSplit{}, RandomThresholdSplit{}} {
+		// when we train with each strategy:
+		forest, err := TrainForestWithOptions(points, NewTrainOptions(WithTreeDepth(3), WithLeafSize(2), WithSplitStrategy(strategy)))
+		// then every training point can find itself:
+		if err != nil {
+			t.Fatalf("%T: TrainForestWithOptions returned error %v", strategy, err)
+		}
+		for i, point := range points {
+			if !forest.FindPointDedupResults(point)[int32(i)] {
+				t.Errorf("%T: point %d didn't find itself", strategy, i)
+			}
+		}
+	}
+
+	// and either ratio can be left to its default:
+	for _, strategy := range []MeanAbsoluteDeviationSplit{{MinSplitRatio: 0.3}, {MaxSplitRatio: 0.9}} {
+		if _, err := TrainForestWithOptions(points, NewTrainOptions(WithSplitStrategy(strategy))); err != nil {
+			t.Errorf("%+v: TrainForestWithOptions returned error %v", strategy, err)
+		}
+	}
+
+	// and bad ratios are rejected:
+	badStrategies := []MeanAbsoluteDeviationSplit{{MinSplitRatio: 0.8, MaxSplitRatio: 0.2}, {MinSplitRatio: -0.1}, {MaxSplitRatio: -0.1}}
+	for _, badStrategy := range badStrategies {
+		if _, err := TrainForestWithOptions(points, NewTrainOptions(WithSplitStrategy(badStrategy))); err == nil || !strings.Contains(err.Error(), "0 < MinSplitRatio") {
+			t.Errorf("err == %v for %+v; expected error about MinSplitRatio", err, badStrategy)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package rbf
+
+// Shape statistics for one tree (or, in `ForestStats.Total`, summed over all trees). These are
+// mostly for tuning TreeDepth and LeafSize: e.g. lots of depth-limited leaves means the trees
+// want to be deeper, and lots of degenerate splits means the features don't separate the data
+// much at that leaf size.
+type TreeStats struct {
+	NumInternalNodes int
+	NumLeaves        int
+	NumRows          int
+	MaxDepth         int
+
+	// Leaf size -> number of leaves of that size.
+	LeafSizes map[int]int
+	// LeafDepths[d] is the number of leaves at depth d (the root is at depth 0).
+	LeafDepths []int
+	// Leaves that couldn't be split further because the tree was as deep as allowed, vs. all
+	// other leaves (which had too few rows to split, or all the same label, or in compact trees
+	// couldn't be split by any feature).
+	NumDepthLimitedLeaves int
+	NumSizeLimitedLeaves  int
+
+	// Splits that sent every row the same way (so one side is empty).
+	NumDegenerateSplits int
+	// The average over splits of (rows on the smaller side) / (rows at the node): 0.5 means
+	// every split was perfectly balanced, 0 means every split was degenerate.
+	MeanSplitBalance float64
+	// Feature number -> number of splits on that feature.
+	FeatureUsage map[int32]int
+}
+
+type ForestStats struct {
+	Trees []TreeStats
+	Total TreeStats
+}
+
+// Gather shape statistics for each tree, and in aggregate.
+func (forest RandomBinaryForest) Stats() ForestStats {
+	stats := ForestStats{Trees: make([]TreeStats, len(forest.Trees)), Total: newTreeStats()}
+	var totalBalance float64
+	for i, tree := range forest.Trees {
+		stats.Trees[i] = tree.Stats()
+		stats.Total.add(stats.Trees[i])
+		totalBalance += stats.Trees[i].MeanSplitBalance * float64(stats.Trees[i].NumInternalNodes)
+	}
+	if stats.Total.NumInternalNodes > 0 {
+		stats.Total.MeanSplitBalance = totalBalance / float64(stats.Total.NumInternalNodes)
+	}
+	return stats
+}
+
+// Gather shape statistics for one tree.
+func (tree RandomBinaryTree) Stats() TreeStats {
+	stats := newTreeStats()
+	var totalBalance float64
+	visitLeaf := func(arrayPos int32, depth int) {
+		leafSize := len(tree.leafIndices(arrayPos))
+		stats.NumLeaves += 1
+		stats.NumRows += leafSize
+		stats.LeafSizes[leafSize] += 1
+		for len(stats.LeafDepths) <= depth {
+			stats.LeafDepths = append(stats.LeafDepths, 0)
+		}
+		stats.LeafDepths[depth] += 1
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		if tree.isDepthLimited(arrayPos, depth) {
+			stats.NumDepthLimitedLeaves += 1
+		} else {
+			stats.NumSizeLimitedLeaves += 1
+		}
+	}
+	visitSplit := func(arrayPos int32, depth int, leftRows, rightRows int) {
+		stats.NumInternalNodes += 1
+		featureNum, _ := tree.split(arrayPos)
+		stats.FeatureUsage[featureNum] += 1
+		if leftRows == 0 || rightRows == 0 {
+			stats.NumDegenerateSplits += 1
+		}
+		totalBalance += splitBalance(leftRows, rightRows)
+	}
+	tree.walk(visitLeaf, visitSplit)
+	if stats.NumInternalNodes > 0 {
+		stats.MeanSplitBalance = totalBalance / float64(stats.NumInternalNodes)
+	}
+	return stats
+}
+
+// Visit every node depth-first (left to right): leaves with their depth, and internal nodes
+// (after their children) with their depth and the number of rows under each child.
+func (tree RandomBinaryTree) walk(visitLeaf func(arrayPos int32, depth int), visitSplit func(arrayPos int32, depth int, leftRows, rightRows int)) {
+	// Returns the number of rows under the node.
+	var visit func(arrayPos int32, depth int) int
+	visit = func(arrayPos int32, depth int) int {
+		if tree.isLeaf(arrayPos) {
+			visitLeaf(arrayPos, depth)
+			return len(tree.leafIndices(arrayPos))
+		}
+		left, right := tree.children(arrayPos)
+		leftRows, rightRows := visit(left, depth+1), visit(right, depth+1)
+		visitSplit(arrayPos, depth, leftRows, rightRows)
+		return leftRows + rightRows
+	}
+	visit(0, 0)
+}
+
+// (rows on the smaller side) / (rows at the node), or 0 for a split with no rows.
+func splitBalance(leftRows, rightRows int) float64 {
+	if leftRows+rightRows == 0 {
+		return 0
+	}
+	smaller := leftRows
+	if rightRows < smaller {
+		smaller = rightRows
+	}
+	return float64(smaller) / float64(leftRows+rightRows)
+}
+
+func newTreeStats() TreeStats {
+	return TreeStats{LeafSizes: map[int]int{}, FeatureUsage: map[int32]int{}}
+}
+
+// Add another tree's counts to these ones (everything except MeanSplitBalance, which isn't a count).
+func (stats *TreeStats) add(other TreeStats) {
+	stats.NumInternalNodes += other.NumInternalNodes
+	stats.NumLeaves += other.NumLeaves
+	stats.NumRows += other.NumRows
+	if other.MaxDepth > stats.MaxDepth {
+		stats.MaxDepth = other.MaxDepth
+	}
+	for leafSize, count := range other.LeafSizes {
+		stats.LeafSizes[leafSize] += count
+	}
+	for depth, count := range other.LeafDepths {
+		for len(stats.LeafDepths) <= depth {
+			stats.LeafDepths = append(stats.LeafDepths, 0)
+		}
+		stats.LeafDepths[depth] += count
+	}
+	stats.NumDepthLimitedLeaves += other.NumDepthLimitedLeaves
+	stats.NumSizeLimitedLeaves += other.NumSizeLimitedLeaves
+	stats.NumDegenerateSplits += other.NumDegenerateSplits
+	for featureNum, count := range other.FeatureUsage {
+		stats.FeatureUsage[featureNum] += count
+	}
+}
@@ -0,0 +1,60 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreeStats(t *testing.T) {
+	// given the test tree (one split, two depth-limited leaves with one row each):
+	tree := NewTestTree()
+	// when we get its stats:
+	stats := tree.Stats()
+	// then:
+	expected := TreeStats{
+		NumInternalNodes:      1,
+		NumLeaves:             2,
+		NumRows:               2,
+		MaxDepth:              1,
+		LeafSizes:             map[int]int{1: 2},
+		LeafDepths:            []int{0, 2},
+		NumDepthLimitedLeaves: 2,
+		NumSizeLimitedLeaves:  0,
+		NumDegenerateSplits:   0,
+		MeanSplitBalance:      0.5,
+		FeatureUsage:          map[int32]int{0: 1},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("stats == %+v; expected %+v", stats, expected)
+	}
+}
+
+func TestForestStats(t *testing.T) {
+	// given a forest trained on data with lots of duplicates (so some splits are degenerate):
+	points := [][]byte{{0, 0}, {0, 0}, {0, 0}, {0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(4), WithTreeDepth(4), WithLeafSize(2), WithSeed(1)))
+	// when we get its stats:
+	stats := forest.Stats()
+	// then the totals add up:
+	var numLeaves, numDegenerateSplits int
+	for i, treeStats := range stats.Trees {
+		if treeStats.NumRows != len(points) || treeStats.NumLeaves != treeStats.NumDepthLimitedLeaves+treeStats.NumSizeLimitedLeaves {
+			t.Errorf("tree %d: stats %+v don't add up", i, treeStats)
+		}
+		if treeStats.NumLeaves != int(forest.Trees[i].numLeaves) {
+			t.Errorf("tree %d: NumLeaves == %d; expected %d", i, treeStats.NumLeaves, forest.Trees[i].numLeaves)
+		}
+		numLeaves += treeStats.NumLeaves
+		numDegenerateSplits += treeStats.NumDegenerateSplits
+	}
+	if stats.Total.NumLeaves != numLeaves || stats.Total.NumRows != 4*len(points) || stats.Total.NumDegenerateSplits != numDegenerateSplits {
+		t.Errorf("total stats %+v don't add up", stats.Total)
+	}
+	// and the four identical points can't be split apart, so they end up in a depth-limited leaf:
+	if stats.Total.NumDegenerateSplits == 0 || stats.Total.NumDepthLimitedLeaves == 0 {
+		t.Errorf("total stats %+v; expected some degenerate splits and depth-limited leaves", stats.Total)
+	}
+	if stats.Total.MeanSplitBalance <= 0 || stats.Total.MeanSplitBalance > 0.5 {
+		t.Errorf("MeanSplitBalance == %f; expected (0, 0.5]", stats.Total.MeanSplitBalance)
+	}
+}
@@ -12,164 +12,306 @@
 // A = [5, 5, 5, 6, 6, 6] and B = [0, 0, 0, 10, 10, 10], then we want to choose
 // B so that noisy data is less likely to fall on the wrong side of the split).
 //
-// These two goals can conflict, so right now we just use a simple split
+// These two goals can conflict, so by default we just use a simple split
 // function that splits closest to the median. This has the added advantage that
 // you don't need to normalize features to have similar distributions.
 //
-// We have another split function that takes variance into account, but this is
-// currently unused.
+// Other split functions, including one that takes variance into account, can be
+// selected with `TrainOptions.SplitStrategy` (see rbf_split.go).
 package rbf
 
 import (
-	"fmt"
 	"math"
 	"math/rand"
-	"os"
 	"sort"
 	"sync"
-
-	features "github.com/moygit/rbf/features"
-
-	"log"
 )
 
-// TODO: Remove after debugging
-const LOG_FILENAME = "train.log"
-
-var treeStatsFile *os.File
-var logger *log.Logger
+// Train a forest with the given parameters. This is a shortcut for `TrainForestWithOptions` that
+// panics if the parameters or data are invalid.
+func TrainForest(featureArray [][]byte, numTrees, treeDepth, leafSize, numFeaturesToCompare int32) RandomBinaryForest {
+	forest, err := TrainForestWithOptions(featureArray, TrainOptions{
+		NumTrees:             numTrees,
+		TreeDepth:            treeDepth,
+		LeafSize:             leafSize,
+		NumFeaturesToCompare: numFeaturesToCompare,
+	})
+	check(err)
+	return forest
+}
 
-func init() {
-	file, _ := os.OpenFile(LOG_FILENAME, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0777)
-	logger = log.New(file, "train_rbf ", log.Ldate|log.Ltime|log.Lshortfile)
-	// FOR DEBUGGING ONLY; WILL BE REMOVED
-	treeStatsFile, _ = os.Create("tree_stats.txt")
+// Train a forest. Returns an error (and doesn't train anything) if the options or the data are invalid.
+func TrainForestWithOptions(featureArray [][]byte, opts TrainOptions) (RandomBinaryForest, error) {
+	if err := opts.validate(featureArray); err != nil {
+		return RandomBinaryForest{}, err
+	}
+	template := treeTrainingParams{}
+	if opts.ColumnMajor {
+		columns := NewColumnMatrix(featureArray)
+		template.columns = &columns
+	}
+	return trainForest(featureArray, opts, template), nil
 }
 
-func TrainForest(featureArray [][]byte, numTrees, treeDepth, leafSize, numFeaturesToCompare int32) RandomBinaryForest {
-	numFeatures := int32(len(featureArray[0]))
+// Train the trees in parallel. The params template has whatever the splits need beyond the
+// training options (e.g. labels for a classifier, or a column-major copy of the data); it gets
+// copied and filled in for each tree. featureArray may be nil if the template has columns.
+// Pre-req: opts have been validated against the data.
+func trainForest(featureArray [][]byte, opts TrainOptions, template treeTrainingParams) RandomBinaryForest {
+	var numRows, numFeatures int32
+	if template.columns != nil {
+		numRows, numFeatures = int32(template.columns.NumRows), int32(template.columns.NumFeatures)
+	} else {
+		numRows, numFeatures = int32(len(featureArray)), int32(len(featureArray[0]))
+	}
+	seed := opts.Seed
+	if seed == 0 && !opts.SeedSet {
+		seed = rand.Int63()
+	}
 	// make and train trees in parallel:
-	trees := make([]RandomBinaryTree, numTrees)
+	trees := make([]RandomBinaryTree, opts.NumTrees)
 	var wg sync.WaitGroup
-	for i := int32(0); i < numTrees; i++ {
+	for i := int32(0); i < opts.NumTrees; i++ {
 		wg.Add(1)
 		go func(j int32) {
 			defer wg.Done()
-			trees[j] = trainOneTree(featureArray, treeDepth, leafSize, numFeatures, numFeaturesToCompare)
+			params := template
+			params.featureArray = featureArray
+			params.numRows = numRows
+			params.leafSize = opts.LeafSize
+			params.numFeatures = numFeatures
+			params.numFeaturesToCompare = opts.NumFeaturesToCompare
+			params.splitStrategy = opts.SplitStrategy
+			params.rng = rand.New(rand.NewSource(treeSeed(seed, j)))
+			params.observer = opts.Observer
+			params.treeNum = j
+			trees[j] = trainOneTree(&params, opts.TreeDepth, opts.Layout)
 		}(i)
 	}
 	wg.Wait()
-	treeStatsFile.Close()
-	return RandomBinaryForest{trees}
+	opts.Seed, opts.SeedSet = seed, true
+	return RandomBinaryForest{Trees: trees, featureArray: featureArray, numRows: numRows, trainOptions: opts}
+}
+
+// The seed the forest was trained with (see `TrainOptions.Seed`), even if it was picked at random,
+// so that `WithSeed` can repeat the training. ok is false if we don't know it, e.g. because the
+// forest was read from a reader (the seed isn't serialized).
+func (forest RandomBinaryForest) Seed() (seed int64, ok bool) {
+	return forest.trainOptions.Seed, forest.trainOptions.SeedSet
+}
+
+// Everything we need to train one tree that isn't part of the tree itself (i.e. isn't needed at query time).
+type treeTrainingParams struct {
+	featureArray         [][]byte
+	columns              *ColumnMatrix // if set, we read features from this instead (see rbf_columns.go)
+	scratch              []int32       // numRows of space for `stablePartitionColumn`
+	numRows              int32
+	leafSize             int32
+	numFeatures          int32
+	numFeaturesToCompare int32
+	splitStrategy        SplitStrategy
+	// Each tree gets its own source of randomness, so training is reproducible (see
+	// `TrainOptions.Seed`) and trees don't contend for the global source's lock.
+	rng *rand.Rand
+	// Optional; see `TrainOptions.Observer`.
+	observer TrainObserver
+	treeNum  int32
+
+	// For classifiers only (see rbf_classifier.go): labels[i] is the class of training row i.
+	// If these are set we split on impurity instead of using splitStrategy.
+	labels     []int32
+	numClasses int32
+	criterion  ImpurityCriterion
+
+	// For regressors only (see rbf_regressor.go): targets[i] is the target value of training row i.
+	// If these are set we split on variance reduction instead of using splitStrategy.
+	targets []float64
+}
+
+// Each tree's seed depends only on the forest's seed and the tree's number, so it doesn't matter
+// which order the goroutines run in. We mix the bits (this is splitmix64's finalizer) so that
+// consecutive tree numbers don't give similar seeds.
+func treeSeed(forestSeed int64, treeNum int32) int64 {
+	z := uint64(forestSeed) + (uint64(treeNum)+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
 }
 
 // Allocate space for the tree's component arrays and then
 // call the recursive `calculateOneNode` function which does the real training.
-func trainOneTree(featureArray [][]byte, treeDepth, leafSize, numFeatures, numFeaturesToCompare int32) RandomBinaryTree {
-	rowIndex := make([]int32, len(featureArray))
+func trainOneTree(params *treeTrainingParams, treeDepth int32, layout NodeLayout) RandomBinaryTree {
+	rowIndex := make([]int32, params.numRows)
 	for i := int32(0); i < int32(len(rowIndex)); i++ {
 		rowIndex[i] = i
 	}
-	treeSize := 1 << treeDepth // golang doesn't have integer power...
-	treeFirst := make([]int32, treeSize)
-	treeSecond := make([]int32, treeSize)
-	var numInternalNodes int32
-	var numLeaves int32
-	tree := &RandomBinaryTree{rowIndex, treeFirst, treeSecond, numInternalNodes, numLeaves}
-	tree.calculateOneNode(featureArray, leafSize, numFeatures, numFeaturesToCompare, 0, int32(len(rowIndex)), 0, 0)
+	tree := &RandomBinaryTree{rowIndex: rowIndex}
+	if params.columns != nil {
+		params.scratch = make([]int32, params.numRows)
+	}
+	if layout == CompactLayout {
+		// just the root; `addChildren` appends the rest
+		tree.treeFirst, tree.treeSecond, tree.treeChild = make([]int32, 1), make([]int32, 1), make([]int32, 1)
+		tree.maxDepth = treeDepth
+	} else {
+		treeSize := 1 << treeDepth // golang doesn't have integer power...
+		tree.treeFirst = make([]int32, treeSize)
+		tree.treeSecond = make([]int32, treeSize)
+	}
+	tree.calculateOneNode(params, 0, int32(len(rowIndex)), 0, 0)
 	return *tree
 }
 
 // Calculate the split (or leaf) at one node (and its descendants). So this is doing all the real work of training.
 // Params:
-// - feature array
-// - leaf size, total number of features, and number of features to compare
+// - training params: feature array, leaf size, etc.
 //   (not adding these to the tree struct b/c they're only needed at training time)
 // - indexStart and indexEnd: the view into rowIndex that we're considering right now
 // - treeArrayPos: the position of this node in the tree arrays
-// - TODO: REMOVE depth of this node in the tree
+// - depth of this node in the tree
 // Guarantees:
 // - Parallel calls to `calculateOneNode` will look at non-intersecting views.
 // - Child calls will look at distinct sub-views of this view.
 // - No two calls to `calculateOneNode` will have the same treeArrayPos
-func (tree *RandomBinaryTree) calculateOneNode(featureArray [][]byte,
-	leafSize, numFeatures, numFeaturesToCompare,
-	indexStart, indexEnd int32, treeArrayPos int, depth int) {
-	if 2*treeArrayPos+2 >= len(tree.treeFirst) {
+func (tree *RandomBinaryTree) calculateOneNode(params *treeTrainingParams, indexStart, indexEnd int32, treeArrayPos int32, depth int) {
+	if tree.isDepthLimited(treeArrayPos, depth) {
 		// Special termination condition to regulate depth.
-		tree.treeFirst[treeArrayPos], tree.treeSecond[treeArrayPos] = high_bit_1^indexStart, high_bit_1^indexEnd
+		tree.setLeaf(treeArrayPos, indexStart, indexEnd)
 		// TODO: remove numLeaves
 		tree.numLeaves += 1
-		// TODO: remove debugging output
-		fmt.Fprintf(treeStatsFile, "%d,%d,depth-based-leaf,%d,%d,%d,%d,%d,%d,\n", treeArrayPos,
-			depth, indexStart, indexEnd, indexEnd-indexStart, 0, 0, 0)
+		params.observe(NodeEvent{TreeNum: params.treeNum, ArrayPos: treeArrayPos, Depth: int32(depth),
+			Kind: DepthLimitedLeaf, IndexStart: indexStart, IndexEnd: indexEnd})
 		return
 	}
 
-	if indexEnd-indexStart < leafSize {
+	if indexEnd-indexStart < params.leafSize || params.isPure(tree.rowIndex[indexStart:indexEnd]) {
 		// Not enough items left to split. Make a leaf.
-		// logger.Printf("DEBUG: making leaf")
-		tree.treeFirst[treeArrayPos], tree.treeSecond[treeArrayPos] = high_bit_1^indexStart, high_bit_1^indexEnd
+		tree.setLeaf(treeArrayPos, indexStart, indexEnd)
 		// TODO: remove numLeaves
 		tree.numLeaves += 1
-		// TODO: remove debugging output
-		fmt.Fprintf(treeStatsFile, "%d,%d,size-based-leaf,%d,%d,%d,%d,%d,%d,\n", treeArrayPos, depth,
-			indexStart, indexEnd, indexEnd-indexStart, 0, 0, 0)
+		params.observe(NodeEvent{TreeNum: params.treeNum, ArrayPos: treeArrayPos, Depth: int32(depth),
+			Kind: SizeLimitedLeaf, IndexStart: indexStart, IndexEnd: indexEnd})
 	} else {
 		// Not a leaf. Get a random subset of numFeaturesToCompare features, find the best one, and split this node.
 		featureNum, featureSplitValue, indexSplit :=
-			splitNode(featureArray, tree.rowIndex, numFeatures, numFeaturesToCompare, indexStart, indexEnd)
-
-		// TODO: remove this (no longer an issue)
-		if indexSplit == indexStart || indexSplit == indexEnd {
-			logger.Printf("DEBUG: bad split; feature-num: %d, count: %d", featureNum, indexEnd-indexStart)
+			splitNode(params, tree.rowIndex, indexStart, indexEnd)
+		if tree.treeChild != nil && (indexSplit == indexStart || indexSplit == indexEnd) {
+			// Compact trees can be very deep, so don't chain degenerate splits all the way down
+			// (e.g. for lots of identical rows). Just stop here.
+			tree.setLeaf(treeArrayPos, indexStart, indexEnd)
+			// TODO: remove numLeaves
+			tree.numLeaves += 1
+			params.observe(NodeEvent{TreeNum: params.treeNum, ArrayPos: treeArrayPos, Depth: int32(depth),
+				Kind: UnsplittableLeaf, IndexStart: indexStart, IndexEnd: indexEnd})
+			return
 		}
-		tree.treeFirst[treeArrayPos], tree.treeSecond[treeArrayPos] = featureNum, int32(featureSplitValue)
+		tree.setSplit(treeArrayPos, featureNum, int32(featureSplitValue))
 		// TODO: remove numInternalNodes
 		tree.numInternalNodes += 1
-		// TODO: remove debugging output
-		fmt.Fprintf(treeStatsFile, "%d,%d,internal,%d,%d,%d,%d,%d,%d,%s\n", treeArrayPos, depth, indexStart, indexEnd,
-			indexEnd-indexStart, indexSplit, featureNum, featureSplitValue, features.CHAR_REVERSE_MAP[featureNum])
-		tree.calculateOneNode(featureArray, leafSize, numFeatures, numFeaturesToCompare, indexStart, indexSplit, (2*treeArrayPos)+1, depth+1)
-		tree.calculateOneNode(featureArray, leafSize, numFeatures, numFeaturesToCompare, indexSplit, indexEnd, (2*treeArrayPos)+2, depth+1)
+		params.observe(NodeEvent{TreeNum: params.treeNum, ArrayPos: treeArrayPos, Depth: int32(depth),
+			Kind: InternalNode, IndexStart: indexStart, IndexEnd: indexEnd,
+			IndexSplit: indexSplit, FeatureNum: featureNum, SplitValue: featureSplitValue})
+		left, right := tree.addChildren(treeArrayPos)
+		tree.calculateOneNode(params, indexStart, indexSplit, left, depth+1)
+		tree.calculateOneNode(params, indexSplit, indexEnd, right, depth+1)
 	}
 }
 
 // Get a random subset of features, find the best one of those features, and split this set of nodes
 // on that feature.
-func splitNode(featureArray [][]byte, rowIndex []int32, numFeatures, numFeaturesToCompare, indexStart, indexEnd int32) (int32, byte, int32) {
-	featuresAlreadySelected := make([]bool, numFeatures)
+func splitNode(params *treeTrainingParams, rowIndex []int32, indexStart, indexEnd int32) (int32, byte, int32) {
+	featureArray := params.featureArray
+	featuresAlreadySelected := make([]bool, params.numFeatures)
 	indexSplit := indexStart
 	var bestFeatureNum, bestFeatureIndex int32
 	var bestFeatureSplitValue byte
-	for attemptNum := 0; attemptNum < 3 && (indexSplit == indexStart || indexSplit == indexEnd); attemptNum++ {
-		featureSubset, featureFrequencies, featureWeightedTotals :=
-			selectRandomFeaturesAndGetFrequencies(featureArray, rowIndex, featuresAlreadySelected, numFeatures, numFeaturesToCompare, indexStart, indexEnd)
-		bestFeatureIndex, bestFeatureSplitValue = getSimpleBestFeature(featureFrequencies, featureWeightedTotals, indexEnd-indexStart)
+	numFeaturesLeft := params.numFeatures
+	for attemptNum := 0; attemptNum < 3 && numFeaturesLeft > 0 && (indexSplit == indexStart || indexSplit == indexEnd); attemptNum++ {
+		// Don't ask for more features than we haven't tried yet, or we'd never find them.
+		numToCompare := params.numFeaturesToCompare
+		if numToCompare > numFeaturesLeft {
+			numToCompare = numFeaturesLeft
+		}
+		numFeaturesLeft -= numToCompare
+		featureSubset := selectRandomFeatures(featuresAlreadySelected, params.rng, params.numFeatures, numToCompare)
+		bestFeatureIndex, bestFeatureSplitValue = params.chooseSplit(rowIndex, featureSubset, indexStart, indexEnd)
 		bestFeatureNum = featureSubset[bestFeatureIndex]
-		indexSplit = quickPartition(rowIndex, featureArray, indexStart, indexEnd, bestFeatureNum, bestFeatureSplitValue)
+		if params.columns != nil {
+			indexSplit = stablePartitionColumn(rowIndex, params.scratch, params.columns.Column(bestFeatureNum), indexStart, indexEnd, bestFeatureSplitValue)
+		} else {
+			indexSplit = quickPartition(rowIndex, featureArray, indexStart, indexEnd, bestFeatureNum, bestFeatureSplitValue)
+		}
 	}
 	return bestFeatureNum, bestFeatureSplitValue, indexSplit
 }
 
-// Select a random subset of features and get the frequencies for those features.
-func selectRandomFeaturesAndGetFrequencies(featureArray [][]byte, rowIndex []int32, featuresAlreadySelected []bool,
-	numFeatures, numFeaturesToCompare, indexStart, indexEnd int32) ([]int32, [][]int32, []int32) {
-	featureSubset := make([]int32, numFeaturesToCompare)
-	featureFrequencies := make([][]int32, numFeaturesToCompare)
-	featureWeightedTotals := make([]int32, numFeaturesToCompare)
+// Whether all the given rows have the same label or target (so there's no point splitting them).
+// Always false for unsupervised training.
+func (params *treeTrainingParams) isPure(rows []int32) bool {
+	switch {
+	case params.labels != nil:
+		for _, rowNum := range rows {
+			if params.labels[rowNum] != params.labels[rows[0]] {
+				return false
+			}
+		}
+		return true
+	case params.targets != nil:
+		for _, rowNum := range rows {
+			if params.targets[rowNum] != params.targets[rows[0]] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
 
+// Select a random subset of features (that haven't already been selected).
+func selectRandomFeatures(featuresAlreadySelected []bool, rng *rand.Rand, numFeatures, numFeaturesToCompare int32) []int32 {
+	featureSubset := make([]int32, numFeaturesToCompare)
 	var featureNum int32
 	for i := int32(0); i < numFeaturesToCompare; i++ {
 		// get one that isn't already selected:
-		for featureNum = rand.Int31n(numFeatures); featuresAlreadySelected[featureNum]; featureNum = rand.Int31n(numFeatures) {
+		for featureNum = rng.Int31n(numFeatures); featuresAlreadySelected[featureNum]; featureNum = rng.Int31n(numFeatures) {
 		}
 		featuresAlreadySelected[featureNum] = true
 		featureSubset[i] = featureNum
-		featureFrequencies[i], featureWeightedTotals[i] =
-			getSingleFeatureFrequencies(rowIndex, featureArray, featureNum, indexStart, indexEnd)
 	}
-	return featureSubset, featureFrequencies, featureWeightedTotals
+	return featureSubset
+}
+
+// Get the frequencies for the selected features and choose the feature and value to split on.
+// Returns: the index into featureSubset of the chosen feature, and the split value.
+func (params *treeTrainingParams) chooseSplit(rowIndex []int32, featureSubset []int32, indexStart, indexEnd int32) (int32, byte) {
+	if params.labels != nil {
+		classFrequencies := make([][]int32, len(featureSubset))
+		for i, featureNum := range featureSubset {
+			classFrequencies[i] = getSingleFeatureClassFrequencies(rowIndex, params.featureArray, params.labels,
+				params.numClasses, featureNum, indexStart, indexEnd)
+		}
+		return getLowestImpurityFeature(classFrequencies, params.numClasses, params.criterion)
+	}
+	if params.targets != nil {
+		targetSums := make([]targetHistogram, len(featureSubset))
+		for i, featureNum := range featureSubset {
+			targetSums[i] = getSingleFeatureTargetSums(rowIndex, params.featureArray, params.targets, featureNum, indexStart, indexEnd)
+		}
+		return getLowestVarianceFeature(targetSums)
+	}
+
+	featureFrequencies := make([][]int32, len(featureSubset))
+	featureWeightedTotals := make([]int32, len(featureSubset))
+	for i, featureNum := range featureSubset {
+		if params.columns != nil {
+			featureFrequencies[i], featureWeightedTotals[i] =
+				getSingleColumnFrequencies(rowIndex, params.columns.Column(featureNum), indexStart, indexEnd)
+		} else {
+			featureFrequencies[i], featureWeightedTotals[i] =
+				getSingleFeatureFrequencies(rowIndex, params.featureArray, featureNum, indexStart, indexEnd)
+		}
+	}
+	return params.splitStrategy.ChooseSplit(featureFrequencies, featureWeightedTotals, indexEnd-indexStart, params.rng)
 }
 
 // Convert a feature column into bins. Since our features are integers in the range [0, 255],
@@ -203,10 +345,10 @@ func getSingleFeatureFrequencies(rowIndex []int32, featureArray [][]byte, featur
 // Split a set of rows on one feature, trying to get close to the median but also maximizing
 // variance.
 //
-// NOTE: We're no longer using the variance but I'm leaving all this (code and comments) unchanged.
-// We'll make it an option later. For our current use our features are sufficiently skewed that
-// using variance is unhelpful, so we simply find the split closest to the median. So we're using
-// `getSimpleBestFeature` instead of `getBestFeature`.
+// NOTE: By default we don't use the variance, but this is still available as an option (see
+// `MeanAbsoluteDeviationSplit`). For our current use our features are sufficiently skewed that
+// using variance is unhelpful, so we simply find the split closest to the median. So by default
+// we're using `getSimpleBestFeature` instead of `getBestFeature`.
 //
 // We want something as close to the median as possible so as to make the tree more balanced.
 // And we want to calculate the "variance" about this split to compare features.
@@ -292,7 +434,7 @@ func getSimpleBestFeature(featureFrequencies [][]int32, featureWeightedTotals []
 	return int32(bestFeatureNum), byte(bestFeatureSplitValue)
 }
 
-// NOT USED ANY MORE: SEE COMMENT IN splitOneFeature ABOVE
+// Used by getBestFeature: see comment in splitOneFeature above
 type featureSplit struct {
 	totalMoment float32
 	splitValue  int32
@@ -300,10 +442,11 @@ type featureSplit struct {
 	featureNum  int
 }
 
-// NOT USED ANY MORE: SEE COMMENT IN splitOneFeature ABOVE
+// Not used by default: see comment in splitOneFeature above
 //
 // Find the best of the given features, i.e. the one that has a split close to the median and has the highest variance.
-// We only consider features that have a split between the 20th and 80th percentiles.
+// We only consider features that have a split between the 20th and 80th percentiles (or between
+// minSplitRatio and maxSplitRatio for `getBestFeatureInRange`).
 //
 // Params:
 // - featureFrequencies is an array giving the frequency (for that feature) of each integer value in [0, 255].
@@ -316,6 +459,11 @@ const MIN_SPLIT_RATIO = 0.2
 const MAX_SPLIT_RATIO = 0.8
 
 func getBestFeature(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32) (int32, byte) {
+	return getBestFeatureInRange(featureFrequencies, featureWeightedTotals, totalCount, MIN_SPLIT_RATIO, MAX_SPLIT_RATIO)
+}
+
+func getBestFeatureInRange(featureFrequencies [][]int32, featureWeightedTotals []int32, totalCount int32,
+	minSplitRatio, maxSplitRatio float64) (int32, byte) {
 	goodFeatureSplits := make([]featureSplit, len(featureFrequencies))
 	goodCount := 0
 	badFeatureSplits := make([]featureSplit, len(featureFrequencies))
@@ -326,7 +474,7 @@ func getBestFeature(featureFrequencies [][]int32, featureWeightedTotals []int32,
 	for i, freq := range featureFrequencies {
 		totalMoment, splitValue, leftCount := splitOneFeature(freq, featureWeightedTotals[i], totalCount)
 		splitFrac := float64(leftCount) / float64(totalCount)
-		if splitFrac > MIN_SPLIT_RATIO && splitFrac < MAX_SPLIT_RATIO {
+		if splitFrac > minSplitRatio && splitFrac < maxSplitRatio {
 			goodFeatureSplits[goodCount] = featureSplit{totalMoment, splitValue, leftCount, i}
 			goodCount += 1
 		} else {
@@ -352,8 +500,15 @@ func getBestFeature(featureFrequencies [][]int32, featureWeightedTotals []int32,
 
 // quicksort-type partitioning of rowIndex[indexStart..indexEnd) based on whether the
 // feature `featureNum` is less-than-or-equal-to or greater-than splitValue
-// pre-req: the sub-slice we're splitting has at least 1 element (i.e. indexEnd - indexStart is at least 2)
+// pre-req: the sub-slice we're splitting has at least 1 element (i.e. indexEnd - indexStart is at least 1)
 func quickPartition(rowIndex []int32, featureArray [][]byte, indexStart, indexEnd, featureNum int32, splitValue byte) int32 {
+	if indexEnd-indexStart == 1 {
+		// the loop below needs two rows to compare, so decide a single row's side here
+		if featureArray[rowIndex[indexStart]][featureNum] <= splitValue {
+			return indexEnd
+		}
+		return indexStart
+	}
 	for i, j := indexStart, indexEnd-1; i < j; {
 		for i < indexEnd && featureArray[rowIndex[i]][featureNum] <= splitValue {
 			i += 1
@@ -0,0 +1,177 @@
+package rbf
+
+import (
+	"fmt"
+	"math"
+)
+
+// Parameters for `TrainForestWithOptions`. Build one directly, or with `NewTrainOptions` and
+// the `With...` functions, e.g.
+//
+//	opts := rbf.NewTrainOptions(rbf.WithNumTrees(50), rbf.WithLeafSize(20))
+type TrainOptions struct {
+	// Number of trees in the forest.
+	NumTrees int32
+	// Maximum depth of each tree. With the default `ImplicitLayout` the tree arrays have
+	// 2^TreeDepth entries, so this is limited to max_tree_depth; with `CompactLayout` it can be
+	// anything (so set it high to let the trees get as deep as the data needs).
+	TreeDepth int32
+	// Nodes with fewer rows than this become leaves.
+	LeafSize int32
+	// Number of random features to compare at each split. Zero means sqrt(number of features),
+	// the usual random-forest default.
+	NumFeaturesToCompare int32
+	// Seed for the random feature selection. The same seed and data always give the same forest
+	// (byte-for-byte, once serialized). Training picks a seed at random unless Seed is nonzero or
+	// SeedSet is true (`WithSeed` sets both, so it can use zero too); see `RandomBinaryForest.Seed`
+	// for the seed that was actually used.
+	Seed    int64
+	SeedSet bool
+	// How to choose the split at each node. Nil means `MedianSplit`.
+	SplitStrategy SplitStrategy
+	// How `TrainClassifier` measures label impurity (`Gini` by default). Ignored by `TrainForestWithOptions`.
+	Criterion ImpurityCriterion
+	// Optional: gets told about every node as it's trained (see `TrainObserver`).
+	Observer TrainObserver
+	// How to lay out the tree nodes in memory (see `NodeLayout`).
+	Layout NodeLayout
+	// Transpose the training data into a `ColumnMatrix` before training, which makes computing
+	// split histograms much more cache-friendly on wide data, at the cost of a copy of the data.
+	// The trees are the same either way, except for the order of the rows within each leaf.
+	// Only unsupervised training supports this: `TrainClassifier` and `TrainRegressor` return an
+	// error if it's set.
+	ColumnMajor bool
+}
+
+// How tree nodes are stored (see the RandomBinaryTree definition for details).
+type NodeLayout int32
+
+const (
+	// Children of node n are at 2n+1 and 2n+2, so there's no need to store them, but the tree
+	// arrays take 2^TreeDepth entries however many nodes there are. Best for balanced trees.
+	ImplicitLayout NodeLayout = iota
+	// Nodes are stored as they're created, with an extra array of child positions, so memory
+	// is proportional to the number of nodes and trees can be as deep as the data needs. Best
+	// for skewed data.
+	CompactLayout
+)
+
+const default_num_trees = 10
+const default_tree_depth = 20
+const default_leaf_size = 32
+
+// 1 << max_tree_depth has to fit in an int32 array position
+const max_tree_depth = 30
+
+// A functional option for `NewTrainOptions`.
+type TrainOption func(*TrainOptions)
+
+func WithNumTrees(numTrees int32) TrainOption {
+	return func(opts *TrainOptions) { opts.NumTrees = numTrees }
+}
+
+func WithTreeDepth(treeDepth int32) TrainOption {
+	return func(opts *TrainOptions) { opts.TreeDepth = treeDepth }
+}
+
+func WithLeafSize(leafSize int32) TrainOption {
+	return func(opts *TrainOptions) { opts.LeafSize = leafSize }
+}
+
+func WithNumFeaturesToCompare(numFeaturesToCompare int32) TrainOption {
+	return func(opts *TrainOptions) { opts.NumFeaturesToCompare = numFeaturesToCompare }
+}
+
+func WithSeed(seed int64) TrainOption {
+	return func(opts *TrainOptions) { opts.Seed, opts.SeedSet = seed, true }
+}
+
+func WithSplitStrategy(splitStrategy SplitStrategy) TrainOption {
+	return func(opts *TrainOptions) { opts.SplitStrategy = splitStrategy }
+}
+
+func WithCriterion(criterion ImpurityCriterion) TrainOption {
+	return func(opts *TrainOptions) { opts.Criterion = criterion }
+}
+
+func WithObserver(observer TrainObserver) TrainOption {
+	return func(opts *TrainOptions) { opts.Observer = observer }
+}
+
+func WithColumnMajor(columnMajor bool) TrainOption {
+	return func(opts *TrainOptions) { opts.ColumnMajor = columnMajor }
+}
+
+func WithLayout(layout NodeLayout) TrainOption {
+	return func(opts *TrainOptions) { opts.Layout = layout }
+}
+
+// Start with the defaults and apply the given options.
+func NewTrainOptions(options ...TrainOption) TrainOptions {
+	opts := TrainOptions{
+		NumTrees:  default_num_trees,
+		TreeDepth: default_tree_depth,
+		LeafSize:  default_leaf_size,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// Check the options against each other and against the training data, and fill in
+// defaults for NumFeaturesToCompare and SplitStrategy.
+func (opts *TrainOptions) validate(featureArray [][]byte) error {
+	if len(featureArray) == 0 {
+		return fmt.Errorf("rbf: no training data")
+	}
+	if len(featureArray) > math.MaxInt32 {
+		return fmt.Errorf("rbf: too much training data (%d rows; at most %d allowed)", len(featureArray), math.MaxInt32)
+	}
+	numFeatures := len(featureArray[0])
+	if numFeatures == 0 {
+		return fmt.Errorf("rbf: training data has no features")
+	}
+	for i, row := range featureArray {
+		if len(row) != numFeatures {
+			return fmt.Errorf("rbf: training data is ragged: row 0 has %d features but row %d has %d", numFeatures, i, len(row))
+		}
+	}
+	return opts.validateParams(numFeatures)
+}
+
+// The part of `validate` that doesn't look at the data (apart from the number of features).
+func (opts *TrainOptions) validateParams(numFeatures int) error {
+	if opts.NumTrees < 1 {
+		return fmt.Errorf("rbf: NumTrees must be at least 1 (got %d)", opts.NumTrees)
+	}
+	if opts.Layout != ImplicitLayout && opts.Layout != CompactLayout {
+		return fmt.Errorf("rbf: unknown Layout %d", opts.Layout)
+	}
+	if opts.TreeDepth < 1 || (opts.Layout == ImplicitLayout && opts.TreeDepth > max_tree_depth) {
+		return fmt.Errorf("rbf: TreeDepth must be between 1 and %d (got %d); use CompactLayout for deeper trees",
+			max_tree_depth, opts.TreeDepth)
+	}
+	if opts.LeafSize < 1 {
+		return fmt.Errorf("rbf: LeafSize must be at least 1 (got %d)", opts.LeafSize)
+	}
+	if opts.NumFeaturesToCompare == 0 {
+		opts.NumFeaturesToCompare = int32(math.Ceil(math.Sqrt(float64(numFeatures))))
+	}
+	if opts.NumFeaturesToCompare < 1 || int(opts.NumFeaturesToCompare) > numFeatures {
+		return fmt.Errorf("rbf: NumFeaturesToCompare must be between 1 and the number of features, %d (got %d)",
+			numFeatures, opts.NumFeaturesToCompare)
+	}
+	if opts.SplitStrategy == nil {
+		opts.SplitStrategy = MedianSplit{}
+	}
+	if opts.Criterion != Gini && opts.Criterion != Entropy {
+		return fmt.Errorf("rbf: unknown Criterion %d", opts.Criterion)
+	}
+	if validator, ok := opts.SplitStrategy.(interface{ validate() error }); ok {
+		if err := validator.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
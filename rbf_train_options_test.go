@@ -0,0 +1,85 @@
+package rbf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrainOptionsValidation(t *testing.T) {
+	points := [][]byte{{0, 1, 2}, {3, 4, 5}}
+	valid := NewTrainOptions(WithNumTrees(2), WithTreeDepth(3), WithLeafSize(1), WithNumFeaturesToCompare(2))
+
+	runOneTest := func(featureArray [][]byte, opts TrainOptions, expErrSubstring string) {
+		_, err := TrainForestWithOptions(featureArray, opts)
+		if err == nil || !strings.Contains(err.Error(), expErrSubstring) {
+			t.Errorf("opts == %+v: err == %v; expected error containing %q", opts, err, expErrSubstring)
+		}
+	}
+
+	// bad data:
+	runOneTest(nil, valid, "no training data")
+	runOneTest([][]byte{{}, {}}, valid, "no features")
+	runOneTest([][]byte{{0, 1, 2}, {3, 4}}, valid, "ragged")
+	// bad parameters:
+	withOption := func(option TrainOption) TrainOptions {
+		opts := valid
+		option(&opts)
+		return opts
+	}
+	runOneTest(points, withOption(WithNumTrees(0)), "NumTrees")
+	runOneTest(points, withOption(WithTreeDepth(0)), "TreeDepth")
+	runOneTest(points, withOption(WithTreeDepth(64)), "TreeDepth")
+	runOneTest(points, withOption(WithLeafSize(0)), "LeafSize")
+	runOneTest(points, withOption(WithNumFeaturesToCompare(4)), "NumFeaturesToCompare")
+	runOneTest(points, withOption(WithNumFeaturesToCompare(-1)), "NumFeaturesToCompare")
+
+	// and good parameters are fine:
+	if forest, err := TrainForestWithOptions(points, valid); err != nil || len(forest.Trees) != 2 {
+		t.Errorf("TrainForestWithOptions(valid) == (%d trees, %v); expected (2 trees, nil)", len(forest.Trees), err)
+	}
+}
+
+func TestTrainDoesNotHangWhenComparingMostFeatures(t *testing.T) {
+	// given identical rows (so every split attempt is degenerate and we retry with new features)
+	// and more than half the features compared per attempt:
+	points := [][]byte{{5, 5, 5}, {5, 5, 5}, {5, 5, 5}, {5, 5, 5}}
+	// when we train, then we run out of features to try instead of looking for them forever:
+	if _, err := TrainForestWithOptions(points, NewTrainOptions(WithTreeDepth(4), WithLeafSize(1), WithNumFeaturesToCompare(2))); err != nil {
+		t.Errorf("TrainForestWithOptions returned error %v", err)
+	}
+}
+
+func TestSeededTrainingIsReproducible(t *testing.T) {
+	// given some data with enough features that the random feature selection matters:
+	points := newRandomPoints(200, 20, 1, 1)
+	serialize := func(seed int64) string {
+		forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(8), WithTreeDepth(6), WithLeafSize(4), WithSeed(seed)))
+		var builder strings.Builder
+		forest.WriteToWriter(&builder)
+		return builder.String()
+	}
+	// when we train twice with the same seed, then we get byte-identical forests:
+	if serialize(42) != serialize(42) {
+		t.Errorf("training twice with the same seed gave different forests")
+	}
+	// and a different seed gives a different forest:
+	if serialize(42) == serialize(43) {
+		t.Errorf("training with different seeds gave the same forest")
+	}
+	// and zero is a seed like any other:
+	if serialize(0) != serialize(0) || serialize(0) == serialize(42) {
+		t.Errorf("training with seed 0 wasn't reproducible")
+	}
+
+	// and an unseeded forest can be reproduced from the seed it reports:
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(8), WithTreeDepth(6), WithLeafSize(4)))
+	seed, ok := forest.Seed()
+	var builder strings.Builder
+	forest.WriteToWriter(&builder)
+	if !ok || builder.String() != serialize(seed) {
+		t.Errorf("retraining with the reported seed (%d, %v) gave a different forest", seed, ok)
+	}
+	if _, ok := ReadForestFromReader(strings.NewReader(builder.String())).Seed(); ok {
+		t.Errorf("expected a deserialized forest not to know its seed")
+	}
+}
@@ -1,12 +1,34 @@
 package rbf
 
 import (
+	"math"
+	"math/rand"
 	"reflect"
 	"testing"
 )
 
 var result int32
 
+// Random data for tests (the same every time for a given seed). Each value is uniformly random,
+// except that a fraction (1 - density) of them are zeroed, e.g. density 0.2 is mostly zeros like
+// mnist.
+func newRandomPoints(numRows, numFeatures int, density float64, seed int64) [][]byte {
+	rng := rand.New(rand.NewSource(seed))
+	points := make([][]byte, numRows)
+	for i := range points {
+		points[i] = make([]byte, numFeatures)
+		rng.Read(points[i])
+		if density < 1 {
+			for j := range points[i] {
+				if rng.Float64() >= density {
+					points[i][j] = 0
+				}
+			}
+		}
+	}
+	return points
+}
+
 func BenchmarkGetSingleFeatureFrequencies(b *testing.B) {
 	// run getSingleFeatureFrequencies b.N times
 	for n := 0; n < b.N; n++ {
@@ -181,4 +203,85 @@ func TestQuickPartition(t *testing.T) {
 	expSplit = 0
 	expRowIndex = []int32{}
 	runOneTest(rowIndex, features, splitValue, expSplit, expRowIndex)
+
+	// given a single row:
+	rowIndex = []int32{0}
+	features = [][]byte{{10}}
+	indexStart, indexEnd = 0, 1
+	// when we split at its own value, then it goes left:
+	runOneTest(rowIndex, features, 10, 1, []int32{0})
+	// and when we split below it, then it goes right:
+	runOneTest(rowIndex, features, 9, 0, []int32{0})
+}
+
+func TestEveryPointFindsItselfWithLeafSizeOne(t *testing.T) {
+	// given random points:
+	points := newRandomPoints(200, 4, 1, 1)
+	for _, layout := range []NodeLayout{ImplicitLayout, CompactLayout} {
+		// when we train with leaves of (down to) a single row:
+		forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(3), WithTreeDepth(12), WithLeafSize(1),
+			WithLayout(layout), WithSeed(1)))
+		// then every tree finds every training point:
+		for treeNum, tree := range forest.Trees {
+			for i, point := range points {
+				found := false
+				for _, index := range tree.findPoint(point) {
+					found = found || index == int32(i)
+				}
+				if !found {
+					t.Errorf("layout %d, tree %d: point %d didn't find itself", layout, treeNum, i)
+				}
+			}
+		}
+	}
+}
+
+func TestCompactLayout(t *testing.T) {
+	// given one-hot rows, which can only be split one row at a time (so the tree is as deep as
+	// there are rows, which the implicit layout can't do):
+	points := make([][]byte, 64)
+	for i := range points {
+		points[i] = make([]byte, len(points))
+		points[i][i] = 1
+	}
+	opts := NewTrainOptions(WithNumTrees(2), WithTreeDepth(100), WithLeafSize(2), WithNumFeaturesToCompare(64), WithSeed(1))
+	if _, err := TrainForestWithOptions(points, opts); err == nil {
+		t.Errorf("expected an error for TreeDepth 100 with the implicit layout")
+	}
+
+	// when we train with the compact layout:
+	opts.Layout = CompactLayout
+	forest, err := TrainForestWithOptions(points, opts)
+	if err != nil {
+		t.Fatalf("TrainForestWithOptions returned error %v", err)
+	}
+	// then the trees are as deep as they need to be, with no wasted space:
+	for treeNum, tree := range forest.Trees {
+		stats := tree.Stats()
+		if stats.MaxDepth != len(points)-1 || stats.NumLeaves != len(points) || len(tree.treeFirst) != 2*len(points)-1 {
+			t.Errorf("tree %d: max depth %d, %d leaves, %d nodes; expected %d, %d, %d", treeNum, stats.MaxDepth,
+				stats.NumLeaves, len(tree.treeFirst), len(points)-1, len(points), 2*len(points)-1)
+		}
+	}
+	// and every point finds just itself:
+	for i, point := range points {
+		if results := forest.FindPointDedupResults(point); len(results) != 1 || !results[int32(i)] {
+			t.Errorf("point %d found %v; expected just itself", i, results)
+		}
+	}
+	// and we can insert into it:
+	if index := forest.Insert(points[0]); !forest.FindPointDedupResults(points[0])[index] {
+		t.Errorf("inserted point didn't find itself")
+	}
+}
+
+func TestCompactLayoutStopsAtUnsplittableRows(t *testing.T) {
+	// given identical rows, when we train a compact tree as deep as possible:
+	points := [][]byte{{5, 5}, {5, 5}, {5, 5}, {5, 5}}
+	forest, _ := TrainForestWithOptions(points, NewTrainOptions(WithNumTrees(1), WithTreeDepth(math.MaxInt32), WithLeafSize(1),
+		WithLayout(CompactLayout)))
+	// then the root is a leaf, instead of a long chain of degenerate splits:
+	if tree := forest.Trees[0]; !tree.isLeaf(0) || len(tree.treeFirst) != 1 {
+		t.Errorf("tree has %d nodes; expected just a root leaf", len(tree.treeFirst))
+	}
 }
@@ -0,0 +1,40 @@
+package rbf
+
+import (
+	"sort"
+)
+
+// A candidate returned by `FindPointVotes`: an index into the training feature-array and the
+// number of trees that returned it.
+type VotedCandidate struct {
+	Index int32
+	Votes int
+}
+
+// Query the forest for a single point and rank the distinct results by how many trees returned
+// them. Trees agreeing on a candidate is a cheap proximity signal that doesn't need the training
+// data (unlike `FindNearest`).
+// Candidates with fewer than minVotes votes are dropped (so minVotes <= 1 keeps everything).
+// Results are sorted by decreasing votes, with ties broken by index so results are deterministic.
+func (forest RandomBinaryForest) FindPointVotes(queryPoint []byte, minVotes int) []VotedCandidate {
+	votes := make(map[int32]int)
+	for _, tree := range forest.Trees {
+		for _, index := range tree.findPoint(queryPoint) {
+			votes[index] += 1
+		}
+	}
+
+	candidates := make([]VotedCandidate, 0, len(votes))
+	for index, count := range votes {
+		if count >= minVotes {
+			candidates = append(candidates, VotedCandidate{index, count})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Votes != candidates[j].Votes {
+			return candidates[i].Votes > candidates[j].Votes
+		}
+		return candidates[i].Index < candidates[j].Index
+	})
+	return candidates
+}
@@ -0,0 +1,33 @@
+package rbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// A tree that's just one leaf containing the given rows.
+func newSingleLeafTree(rowIndex []int32) RandomBinaryTree {
+	return RandomBinaryTree{rowIndex: rowIndex,
+		treeFirst:  []int32{high_bit_1 ^ 0},
+		treeSecond: []int32{high_bit_1 ^ int32(len(rowIndex))}}
+}
+
+func TestFindPointVotes(t *testing.T) {
+	// given three trees that return overlapping candidates:
+	forest := RandomBinaryForest{Trees: []RandomBinaryTree{
+		newSingleLeafTree([]int32{0, 1, 2}),
+		newSingleLeafTree([]int32{2, 1}),
+		newSingleLeafTree([]int32{3, 2}),
+	}}
+	queryPoint := []byte{0}
+	// when we keep everything, then candidates are ordered by votes and then index:
+	expected := []VotedCandidate{{2, 3}, {1, 2}, {0, 1}, {3, 1}}
+	if actual := forest.FindPointVotes(queryPoint, 0); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FindPointVotes(_, 0) == %v; expected %v", actual, expected)
+	}
+	// and when we require 2 votes, then the singletons are dropped:
+	expected = []VotedCandidate{{2, 3}, {1, 2}}
+	if actual := forest.FindPointVotes(queryPoint, 2); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FindPointVotes(_, 2) == %v; expected %v", actual, expected)
+	}
+}